@@ -1,11 +1,10 @@
 package helper
 
 import (
+	"database/sql/driver"
 	"encoding/json"
 	"strings"
 	"time"
-
-	"4d63.com/tz"
 )
 
 const (
@@ -21,7 +20,7 @@ Date Function
 */
 
 func NewDateFromString(dateString string) Date {
-	loc, _ := tz.LoadLocation("Asia/Bangkok")
+	loc := TimeLocation
 	d, err := time.ParseInLocation(DateLayout, dateString, loc)
 	if err != nil {
 		panic(err)
@@ -30,7 +29,7 @@ func NewDateFromString(dateString string) Date {
 }
 
 func NewDateFromStringWithTime(dateString string) Date {
-	loc, _ := tz.LoadLocation("Asia/Bangkok")
+	loc := TimeLocation
 	d, err := time.ParseInLocation(TimestampLayout, dateString, loc)
 	if err != nil {
 		panic(err)
@@ -39,7 +38,7 @@ func NewDateFromStringWithTime(dateString string) Date {
 }
 
 func NewDateFromTime(t time.Time) Date {
-	loc, _ := tz.LoadLocation("Asia/Bangkok")
+	loc := TimeLocation
 	d, err := time.ParseInLocation(DateLayout, t.Format(DateLayout), loc)
 	if err != nil {
 		panic(err)
@@ -75,11 +74,18 @@ func (j Date) String() string {
 	return j.Format(DateLayout)
 }
 
+func (j Date) Value() (driver.Value, error) {
+	if j == (Date{}) {
+		return nil, nil
+	}
+	return j.String(), nil
+}
+
 func (j *Date) GetBSON() (interface{}, error) {
 	if j == nil {
 		return nil, nil
 	}
-	loc, _ := tz.LoadLocation("Asia/Bangkok")
+	loc := TimeLocation
 	t := time.Time(*j)
 	d, err := time.ParseInLocation(TimestampLayout, t.Format(DateLayout), loc)
 	if err != nil {
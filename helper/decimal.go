@@ -0,0 +1,176 @@
+package helper
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+/*
+Decimal is exact decimal number for numeric column,
+value = unscaled * 10^-scale, scale is keep from source
+so "10.5000" is still "10.5000" after Scan and MarshalJSON
+*/
+type Decimal struct {
+	unscaled *big.Int
+	scale    int32
+}
+
+/*
+------------------------
+Decimal Function
+------------------------
+*/
+
+func NewDecimalFromString(s string) (Decimal, error) {
+	text := strings.TrimSpace(s)
+	if text == "" {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	var sign string
+	if text[0] == '-' || text[0] == '+' {
+		sign, text = text[:1], text[1:]
+	}
+	intPart, fracPart := text, ""
+	if index := strings.IndexByte(text, '.'); index != -1 {
+		intPart, fracPart = text[:index], text[index+1:]
+	}
+	if intPart == "" && fracPart == "" || !isDigits(intPart) || !isDigits(fracPart) {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	unscaled, ok := new(big.Int).SetString(sign+intPart+fracPart, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	return Decimal{unscaled: unscaled, scale: int32(len(fracPart))}, nil
+}
+
+func NewDecimalFromInt(val int64) Decimal {
+	return Decimal{unscaled: big.NewInt(val)}
+}
+
+func isDigits(s string) bool {
+	for _, char := range s {
+		if char < '0' || char > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (d Decimal) getUnscaled() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return d.unscaled
+}
+
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+func (d Decimal) IsZero() bool {
+	return d.getUnscaled().Sign() == 0
+}
+
+func (d Decimal) Rat() *big.Rat {
+	denom := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(d.scale)), nil)
+	return new(big.Rat).SetFrac(d.getUnscaled(), denom)
+}
+
+// Float64 is lossy, use for display or calculation which not need exact value
+func (d Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+	return f
+}
+
+// Cmp compare numerically, 1.50 and 1.5 is equal
+func (d Decimal) Cmp(other Decimal) int {
+	return d.Rat().Cmp(other.Rat())
+}
+
+func (d Decimal) Equal(other Decimal) bool {
+	return d.Cmp(other) == 0
+}
+
+// Normalize remove trailing zero of fraction, 1.5000 -> 1.5
+func (d Decimal) Normalize() Decimal {
+	unscaled := new(big.Int).Set(d.getUnscaled())
+	scale := d.scale
+	ten := big.NewInt(10)
+	mod := new(big.Int)
+	for scale > 0 {
+		quo, rem := new(big.Int).QuoRem(unscaled, ten, mod)
+		if rem.Sign() != 0 {
+			break
+		}
+		unscaled = quo
+		scale--
+	}
+	return Decimal{unscaled: unscaled, scale: scale}
+}
+
+func (d Decimal) String() string {
+	unscaled := d.getUnscaled()
+	digits := new(big.Int).Abs(unscaled).String()
+	if d.scale > 0 {
+		if pad := int(d.scale) - len(digits) + 1; pad > 0 {
+			digits = strings.Repeat("0", pad) + digits
+		}
+		digits = digits[:len(digits)-int(d.scale)] + "." + digits[len(digits)-int(d.scale):]
+	}
+	if unscaled.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// accept json number and string
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), "\"")
+	if s == "null" {
+		return nil
+	}
+	parsed, err := NewDecimalFromString(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+func (d *Decimal) Scan(value interface{}) error {
+	var text string
+	switch v := value.(type) {
+	case nil:
+		*d = Decimal{}
+		return nil
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	case int64:
+		text = strconv.FormatInt(v, 10)
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Errorf("cannot scan type %T into Decimal", value)
+	}
+	parsed, err := NewDecimalFromString(text)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
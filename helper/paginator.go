@@ -2,20 +2,51 @@ package helper
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"math"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/Pheethy/sqlx"
+	"github.com/spf13/cast"
 )
 
 const (
 	PSQL_TOTAL_ROW_KEY = "total_row"
 )
 
+// DefaultMaxPerPage is max of PerPage when SetMaxPerPage is not called, 0 is unlimited
+var DefaultMaxPerPage = 100
+
+var (
+	ErrInvalidPage    = errors.New("page must be greater than 0")
+	ErrInvalidPerPage = errors.New("per page must be between 1 and max per page")
+)
+
 type Paginator struct {
 	Page            int `json:"page"`
 	PerPage         int `json:"per_page"`
 	TotalPages      int `json:"total_page"`
 	TotalEntrySizes int `json:"total_rows"`
+	maxPerPage      int
+}
+
+/*
+PaginateTotaler is source of total row, orm.Mapper implement it
+with total_row of the last row (already read by orm)
+*/
+type PaginateTotaler interface {
+	GetPaginateTotal() int
+}
+
+// PaginatorEnvelope is JSON response of page, {"data": [...], "page": 1, ..., "has_next": true}
+type PaginatorEnvelope struct {
+	Data interface{} `json:"data"`
+	Paginator
+	HasNext bool `json:"has_next"`
+	HasPrev bool `json:"has_prev"`
 }
 
 func (p Paginator) String() string {
@@ -31,22 +62,78 @@ func NewPaginatorWithParams(page int, perPage int) Paginator {
 	return Paginator{Page: page, PerPage: perPage}
 }
 
+// SetMaxPerPage override DefaultMaxPerPage of this paginator, 0 is unlimited
+func (p Paginator) SetMaxPerPage(max int) Paginator {
+	p.maxPerPage = max
+	return p
+}
+
+// Validate page >= 1 and 1 <= per page <= max per page
+func (p Paginator) Validate() error {
+	if p.Page < 1 {
+		return fmt.Errorf("%w: %d", ErrInvalidPage, p.Page)
+	}
+	max := DefaultMaxPerPage
+	if p.maxPerPage != 0 {
+		max = p.maxPerPage
+	}
+	if p.PerPage < 1 || (max > 0 && p.PerPage > max) {
+		return fmt.Errorf("%w: %d (max %d)", ErrInvalidPerPage, p.PerPage, max)
+	}
+	return nil
+}
+
+// Limit for LIMIT of query
+func (p Paginator) Limit() int {
+	return p.PerPage
+}
+
+// Offset for OFFSET of query, 0 when page or per page is less than 1 (Validate is not called)
+func (p Paginator) Offset() int {
+	if p.Page < 1 || p.PerPage < 1 {
+		return 0
+	}
+	return (p.Page - 1) * p.PerPage
+}
+
+func (p Paginator) HasNext() bool {
+	return p.Page < p.TotalPages
+}
+
+func (p Paginator) HasPrev() bool {
+	return p.Page > 1
+}
+
 func (p *Paginator) SetPaginatorByAllRows(allRows int) {
 	p.setTotalEntrySizes(allRows)
 	p.setTotalPages()
 }
 
+// SetTotalFromMapper set total from orm.Mapper (total_row column) without read rows again
+func (p *Paginator) SetTotalFromMapper(mapper PaginateTotaler) {
+	p.SetPaginatorByAllRows(mapper.GetPaginateTotal())
+}
+
 func (p *Paginator) setTotalEntrySizes(allRows int) {
 	p.TotalEntrySizes = allRows
 }
 
 func (p *Paginator) setTotalPages() {
+	if p.PerPage < 1 {
+		p.TotalPages = 0
+		return
+	}
 	totalRows := p.TotalEntrySizes
 	perPage := p.PerPage
 	totalPage := math.Ceil(float64(totalRows) / float64(perPage))
 	p.TotalPages = int(totalPage)
 }
 
+/*
+SetTotalFromRows read the next row of rows to get total_row.
+
+Deprecated: the row is consumed, use SetTotalFromMapper or SetTotalFromValues in rows.Next() loop
+*/
 func (p *Paginator) SetTotalFromRows(rows *sqlx.Rows) error {
 	columns, err := rows.Columns()
 	if err != nil {
@@ -56,16 +143,90 @@ func (p *Paginator) SetTotalFromRows(rows *sqlx.Rows) error {
 	if err != nil {
 		return err
 	}
-	if columns != nil && values != nil {
-		if len(columns) > 0 && len(values) > 0 {
-			for index, column := range columns {
-				if column == PSQL_TOTAL_ROW_KEY {
-					total := int(values[index].(int64))
-					p.setTotalEntrySizes(total)
-					p.setTotalPages()
-				}
-			}
+	return p.SetTotalFromValues(columns, values)
+}
+
+// SetTotalFromValues set total from total_row of scanned row, total can be any integer type, numeric or text
+func (p *Paginator) SetTotalFromValues(columns []string, values []interface{}) error {
+	for index, column := range columns {
+		if column != PSQL_TOTAL_ROW_KEY || index >= len(values) {
+			continue
+		}
+		total, err := ParseTotal(values[index])
+		if err != nil {
+			return fmt.Errorf("%s: %w", PSQL_TOTAL_ROW_KEY, err)
 		}
+		p.SetPaginatorByAllRows(total)
 	}
 	return nil
 }
+
+// ParseTotal total row of scanned value, can be any integer type, numeric or text
+func ParseTotal(val interface{}) (int, error) {
+	if b, ok := val.([]byte); ok {
+		/* numeric is []byte from driver */
+		val = string(b)
+	}
+	return cast.ToIntE(val)
+}
+
+/*
+LinkHeader is RFC 8288 Link header of first, prev, next and last page,
+page and per_page query of base url is replaced. example
+<https://api/orders?page=1&per_page=20>; rel="first", <https://api/orders?page=3&per_page=20>; rel="next"
+*/
+func (p Paginator) LinkHeader(base *url.URL) string {
+	var link = func(page int, rel string) string {
+		u := *base
+		query := u.Query()
+		query.Set("page", strconv.Itoa(page))
+		query.Set("per_page", strconv.Itoa(p.PerPage))
+		u.RawQuery = query.Encode()
+		return fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel)
+	}
+
+	var links = make([]string, 0, 4)
+	links = append(links, link(1, "first"))
+	if p.HasPrev() {
+		links = append(links, link(p.Page-1, "prev"))
+	}
+	if p.HasNext() {
+		links = append(links, link(p.Page+1, "next"))
+	}
+	if p.TotalPages > 0 {
+		links = append(links, link(p.TotalPages, "last"))
+	}
+	return strings.Join(links, ", ")
+}
+
+// Envelope wrap data of page with paginator and has_next/has_prev
+func (p Paginator) Envelope(data interface{}) PaginatorEnvelope {
+	return PaginatorEnvelope{Data: data, Paginator: p, HasNext: p.HasNext(), HasPrev: p.HasPrev()}
+}
+
+/*
+CursorPaginator is keyset pagination, Cursor is opaque token from NextCursor or PrevCursor
+of previous page, empty Cursor is first page. see orm.Keyset
+*/
+type CursorPaginator struct {
+	Cursor     string `json:"cursor,omitempty"`
+	PerPage    int    `json:"per_page"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	HasNext    bool   `json:"has_next"`
+	HasPrev    bool   `json:"has_prev"`
+}
+
+func (p CursorPaginator) String() string {
+	ju, _ := json.Marshal(p)
+	return string(ju)
+}
+
+func NewCursorPaginator(cursor string, perPage int) CursorPaginator {
+	return CursorPaginator{Cursor: cursor, PerPage: perPage}
+}
+
+// Limit is PerPage + 1, the extra row tell there is next page
+func (p CursorPaginator) Limit() int {
+	return p.PerPage + 1
+}
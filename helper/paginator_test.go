@@ -0,0 +1,93 @@
+package helper_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/Pheethy/psql/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaginator(t *testing.T) {
+	t.Run("error_validate_bounds", func(t *testing.T) {
+		assert.ErrorIs(t, helper.NewPaginatorWithParams(0, 20).Validate(), helper.ErrInvalidPage)
+		assert.ErrorIs(t, helper.NewPaginatorWithParams(-1, 20).Validate(), helper.ErrInvalidPage)
+		assert.ErrorIs(t, helper.NewPaginatorWithParams(1, 0).Validate(), helper.ErrInvalidPerPage)
+		assert.ErrorIs(t, helper.NewPaginatorWithParams(1, -5).Validate(), helper.ErrInvalidPerPage)
+		assert.ErrorIs(t, helper.NewPaginatorWithParams(1, helper.DefaultMaxPerPage+1).Validate(), helper.ErrInvalidPerPage)
+		assert.ErrorIs(t, helper.NewPaginatorWithParams(1, 30).SetMaxPerPage(25).Validate(), helper.ErrInvalidPerPage)
+
+		assert.NoError(t, helper.NewPaginatorWithParams(1, helper.DefaultMaxPerPage).Validate())
+		assert.NoError(t, helper.NewPaginatorWithParams(1, 500).SetMaxPerPage(500).Validate())
+	})
+
+	t.Run("success_offset_not_negative", func(t *testing.T) {
+		assert.Equal(t, 40, helper.NewPaginatorWithParams(3, 20).Offset())
+		/* Validate is not called */
+		assert.Equal(t, 0, helper.NewPaginatorWithParams(0, 20).Offset())
+		assert.Equal(t, 0, helper.NewPaginatorWithParams(-2, 20).Offset())
+		assert.Equal(t, 0, helper.NewPaginatorWithParams(3, -20).Offset())
+	})
+
+	t.Run("success_link_header", func(t *testing.T) {
+		base, _ := url.Parse("https://api/orders?status=paid")
+
+		first := helper.NewPaginatorWithParams(1, 10)
+		first.SetPaginatorByAllRows(25)
+		assert.Equal(t,
+			`<https://api/orders?page=1&per_page=10&status=paid>; rel="first", `+
+				`<https://api/orders?page=2&per_page=10&status=paid>; rel="next", `+
+				`<https://api/orders?page=3&per_page=10&status=paid>; rel="last"`,
+			first.LinkHeader(base),
+		)
+
+		last := helper.NewPaginatorWithParams(3, 10)
+		last.SetPaginatorByAllRows(25)
+		assert.Equal(t,
+			`<https://api/orders?page=1&per_page=10&status=paid>; rel="first", `+
+				`<https://api/orders?page=2&per_page=10&status=paid>; rel="prev", `+
+				`<https://api/orders?page=3&per_page=10&status=paid>; rel="last"`,
+			last.LinkHeader(base),
+		)
+
+		only := helper.NewPaginatorWithParams(1, 10)
+		only.SetPaginatorByAllRows(5)
+		assert.Equal(t,
+			`<https://api/orders?page=1&per_page=10&status=paid>; rel="first", `+
+				`<https://api/orders?page=1&per_page=10&status=paid>; rel="last"`,
+			only.LinkHeader(base),
+		)
+	})
+
+	t.Run("success_total_from_values", func(t *testing.T) {
+		var columns = []string{"id", helper.PSQL_TOTAL_ROW_KEY}
+
+		paginator := helper.NewPaginatorWithParams(1, 10)
+		assert.NoError(t, paginator.SetTotalFromValues(columns, []interface{}{1, int64(42)}))
+		assert.Equal(t, 42, paginator.TotalEntrySizes)
+		assert.Equal(t, 5, paginator.TotalPages)
+
+		paginator = helper.NewPaginatorWithParams(1, 10)
+		assert.NoError(t, paginator.SetTotalFromValues(columns, []interface{}{1, "15"}))
+		assert.Equal(t, 15, paginator.TotalEntrySizes)
+		assert.Equal(t, 2, paginator.TotalPages)
+
+		/* numeric from driver */
+		paginator = helper.NewPaginatorWithParams(1, 10)
+		assert.NoError(t, paginator.SetTotalFromValues(columns, []interface{}{1, []byte("7")}))
+		assert.Equal(t, 7, paginator.TotalEntrySizes)
+
+		/* NULL total_row is 0 */
+		paginator = helper.NewPaginatorWithParams(1, 10)
+		assert.NoError(t, paginator.SetTotalFromValues(columns, []interface{}{1, nil}))
+		assert.Equal(t, 0, paginator.TotalEntrySizes)
+		assert.Equal(t, 0, paginator.TotalPages)
+	})
+
+	t.Run("error_total_from_values_not_integer", func(t *testing.T) {
+		paginator := helper.NewPaginatorWithParams(1, 10)
+		err := paginator.SetTotalFromValues([]string{helper.PSQL_TOTAL_ROW_KEY}, []interface{}{"many"})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), helper.PSQL_TOTAL_ROW_KEY)
+	})
+}
@@ -14,6 +14,23 @@ const (
 	TimestampLayout = "2006-01-02 15:04:05" // 2006-01-02 15:04:05, 2006-01-02T15:04:05
 )
 
+// DefaultTimeLocation is default timezone of TimeLocation
+const DefaultTimeLocation = "Asia/Bangkok"
+
+/*
+TimeLocation is timezone of Timestamp, Date and orm autoCreateTime / autoUpdateTime,
+loaded once and shared. replace it at start up, example helper.TimeLocation = time.UTC
+*/
+var TimeLocation = mustLoadLocation(DefaultTimeLocation)
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := tz.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
 type Timestamp time.Time
 
 /*
@@ -26,7 +43,7 @@ func NewTimestampFromString(dateString string) Timestamp {
 	if dateString == "" {
 		return Timestamp(time.Time{})
 	}
-	loc, _ := tz.LoadLocation("Asia/Bangkok")
+	loc := TimeLocation
 	d, err := time.ParseInLocation(TimestampLayout, dateString, loc)
 	if err != nil {
 		panic(err)
@@ -92,7 +109,7 @@ func (j *Timestamp) GetBSON() (interface{}, error) {
 	if (*j) == Timestamp(time.Time{}) {
 		return nil, nil
 	}
-	loc, _ := tz.LoadLocation("Asia/Bangkok")
+	loc := TimeLocation
 	t := time.Time(*j)
 	d, err := time.ParseInLocation(TimestampLayout, t.Format(TimestampLayout), loc)
 	if err != nil {
@@ -131,7 +148,7 @@ func (t *Timestamp) Scan(value interface{}) error {
 		*t = Timestamp(v)
 		return nil
 	case string:
-		loc, _ := tz.LoadLocation("Asia/Bangkok")
+		loc := TimeLocation
 		parsed, err := time.ParseInLocation(TimestampLayout, v, loc)
 		if err != nil {
 			return err
@@ -139,7 +156,7 @@ func (t *Timestamp) Scan(value interface{}) error {
 		*t = Timestamp(parsed)
 		return nil
 	case []byte:
-		loc, _ := tz.LoadLocation("Asia/Bangkok")
+		loc := TimeLocation
 		parsed, err := time.ParseInLocation(TimestampLayout, string(v), loc)
 		if err != nil {
 			return err
@@ -0,0 +1,91 @@
+package orm_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Pheethy/psql/orm"
+	"github.com/Pheethy/sqlx"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v2"
+)
+
+type Ticket struct {
+	TableName  struct{} `json:"-" db:"tickets" pk:"ID"`
+	ID         int      `json:"id" db:"id"`
+	Title      string   `json:"title" db:"title"`
+	CreatorID  int      `json:"creator_id" db:"creator_id"`
+	ApproverID *int     `json:"approver_id" db:"approver_id"`
+	Creator    *Staff   `json:"creator" db:"-" fk:"fk_field1:CreatorID,fk_field2:ID,alias:creator"`
+	Approver   *Staff   `json:"approver" db:"-" fk:"fk_field1:ApproverID,fk_field2:ID,alias:approver"`
+}
+
+type Staff struct {
+	TableName struct{} `json:"-" db:"staffs" pk:"ID"`
+	ID        int      `json:"id" db:"id"`
+	Name      string   `json:"name" db:"name"`
+}
+
+type DuplicateAliasTicket struct {
+	TableName struct{} `json:"-" db:"tickets" pk:"ID"`
+	ID        int      `json:"id" db:"id"`
+	CreatorID int      `json:"creator_id" db:"creator_id"`
+	Creator   *Staff   `json:"creator" db:"-" fk:"fk_field1:CreatorID,fk_field2:ID,alias:staff"`
+	Approver  *Staff   `json:"approver" db:"-" fk:"fk_field1:CreatorID,fk_field2:ID,alias:staff"`
+}
+
+func TestAlias(t *testing.T) {
+	var columns = []string{
+		"tickets.id", "tickets.title", "tickets.creator_id", "tickets.approver_id",
+		"creator.id", "creator.name",
+		"approver.id", "approver.name",
+	}
+	var newRows = func(t *testing.T, rows *sqlmock.Rows) *sqlx.Rows {
+		db, dbmock, err := sqlmock.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		dbmock.ExpectQuery(`SELECT (.+) tickets`).WillReturnRows(rows)
+		sqlxRows, err := sqlx.NewDb(db, "sqlmock").Queryx(`SELECT * FROM tickets`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { sqlxRows.Close() })
+		return sqlxRows
+	}
+
+	t.Run("success_selector_with_alias", func(t *testing.T) {
+		assert.Equal(t, `creator.id "creator.id",creator.name "creator.name"`, orm.GetSelector(new(Staff), "creator"))
+		assert.Equal(t, `staffs.id "staffs.id",staffs.name "staffs.name"`, orm.GetSelector(new(Staff)))
+	})
+
+	t.Run("success_route_column_by_alias", func(t *testing.T) {
+		rows := newRows(t, sqlmock.NewRows(columns).
+			AddRow(1, "Refund", 10, 20, 10, "Alice", 20, "Bob").
+			AddRow(2, "Exchange", 20, nil, 20, "Bob", nil, nil).
+			AddRow(3, "Repair", 20, 10, 20, "Bob", 10, "Alice"))
+
+		mapper, err := orm.Orm(new(Ticket), rows, orm.NewMapperOption().SetStrict())
+		assert.NoError(t, err)
+
+		tickets := mapper.GetData().([]*Ticket)
+		assert.Len(t, tickets, 3)
+		assert.Equal(t, "Alice", tickets[0].Creator.Name)
+		assert.Equal(t, "Bob", tickets[0].Approver.Name)
+		assert.Equal(t, "Bob", tickets[1].Creator.Name)
+		assert.Nil(t, tickets[1].Approver)
+		assert.Equal(t, "Bob", tickets[2].Creator.Name)
+		assert.Equal(t, "Alice", tickets[2].Approver.Name)
+	})
+
+	t.Run("error_duplicate_alias", func(t *testing.T) {
+		err := orm.ValidateModels(new(DuplicateAliasTicket))
+		assert.ErrorIs(t, err, orm.ErrDuplicateAlias)
+
+		var mappingErr *orm.MappingError
+		assert.True(t, errors.As(err, &mappingErr))
+		assert.Equal(t, "Approver", mappingErr.Field)
+		assert.NoError(t, orm.ValidateModels(new(Ticket)))
+	})
+}
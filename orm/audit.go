@@ -0,0 +1,339 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Pheethy/sqlx"
+)
+
+var TAG_AUDIT = "audit"
+
+/*
+AUDIT_TABLE_NAME is table of audit record, columns are
+table_name, record_id, action, old_values (jsonb), new_values (jsonb), actor, request_id, created_at
+*/
+var AUDIT_TABLE_NAME = "audit_logs"
+
+const (
+	AUDIT_ACTION_INSERT = "insert"
+	AUDIT_ACTION_UPDATE = "update"
+	AUDIT_ACTION_DELETE = "delete"
+)
+
+type auditContextKey string
+
+const (
+	auditActorKey     auditContextKey = "orm.audit.actor"
+	auditRequestIDKey auditContextKey = "orm.audit.request_id"
+)
+
+var auditColumns = []string{"table_name", "record_id", "action", "old_values", "new_values", "actor", "request_id", "created_at"}
+
+type auditRecord struct {
+	recordID string
+	action   string
+	old      map[string]interface{} // column -> value, nil on insert
+	new      map[string]interface{} // column -> value, nil on delete
+}
+
+// WithAuditActor set actor (user id, username) of audit record written with ctx
+func WithAuditActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, auditActorKey, actor)
+}
+
+// WithAuditRequestID set request id of audit record written with ctx
+func WithAuditRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, auditRequestIDKey, requestID)
+}
+
+func AuditActor(ctx context.Context) string {
+	actor, _ := ctx.Value(auditActorKey).(string)
+	return actor
+}
+
+func AuditRequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(auditRequestIDKey).(string)
+	return requestID
+}
+
+// txBeginner is *sqlx.DB (or *sqlx.Conn) which audited write begin transaction with
+type txBeginner interface {
+	BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error)
+}
+
+/*
+auditTx run write of audit model and its audit record in the same transaction,
+*sqlx.Tx is used as is, *sqlx.DB begin and commit transaction (rollback when write is failed).
+return ErrAuditTransaction when exec can not begin transaction, model without audit use exec directly
+*/
+func (w writeModel) auditTx(ctx context.Context, exec sqlx.ExtContext, write func(exec sqlx.ExtContext) error) error {
+	if !w.audit {
+		return write(exec)
+	}
+	if _, ok := exec.(*sqlx.Tx); ok {
+		return write(exec)
+	}
+	beginner, ok := exec.(txBeginner)
+	if !ok {
+		return fmt.Errorf("%w: got %T", ErrAuditTransaction, exec)
+	}
+	tx, err := beginner.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := write(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// inTransaction every write is rolled back together when it is failed
+func (w writeModel) inTransaction(exec sqlx.ExtContext) bool {
+	_, ok := exec.(*sqlx.Tx)
+	return ok || w.audit
+}
+
+/*
+auditSelect select current row of models by key columns with FOR UPDATE before write,
+result is snapshot of row by key. nil when model is not audit
+*/
+func (w writeModel) auditSelect(ctx context.Context, exec sqlx.ExtContext, elems []reflect.Value, keyColumns []writeColumn, condition string) (map[string]map[string]interface{}, error) {
+	if !w.audit || len(elems) == 0 {
+		return nil, nil
+	}
+	var olds = make(map[string]map[string]interface{}, len(elems))
+	var seen = make(map[string]bool, len(elems))
+	var tuples = make([]reflect.Value, 0, len(elems))
+	for _, elem := range elems {
+		key, err := w.keyOf(elem, keyColumns)
+		if err != nil {
+			return nil, err
+		}
+		/* new row with DEFAULT key has no old value */
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		tuples = append(tuples, elem)
+	}
+
+	chunkSize := maxBindParameters / len(keyColumns)
+	for start := 0; start < len(tuples); start += chunkSize {
+		end := start + chunkSize
+		if end > len(tuples) {
+			end = len(tuples)
+		}
+		var args = make([]interface{}, 0, (end-start)*len(keyColumns))
+		var placeholders = make([]string, 0, end-start)
+		for _, elem := range tuples[start:end] {
+			var tuplePlaceholders = make([]string, 0, len(keyColumns))
+			for _, column := range keyColumns {
+				val, err := getColumnValue(elem, column, w.options)
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, val)
+				tuplePlaceholders = append(tuplePlaceholders, fmt.Sprintf("$%d", len(args)))
+			}
+			placeholders = append(placeholders, "("+strings.Join(tuplePlaceholders, ",")+")")
+		}
+		where := fmt.Sprintf(`(%s) IN (%s)`, joinColumns(keyColumns), strings.Join(placeholders, ","))
+		if condition != "" {
+			where += " AND " + condition
+		}
+		query := fmt.Sprintf(`SELECT %s FROM %s WHERE %s FOR UPDATE`, joinColumns(w.columns), quoteIdentifier(w.table), where)
+		if err := w.scanAuditRows(ctx, exec, query, args, elems[0].Type().Elem(), keyColumns, olds); err != nil {
+			return nil, err
+		}
+	}
+	return olds, nil
+}
+
+func (w writeModel) scanAuditRows(ctx context.Context, exec sqlx.ExtContext, query string, args []interface{}, modelType reflect.Type, keyColumns []writeColumn, olds map[string]map[string]interface{}) error {
+	rows, err := exec.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+	for row := 0; rows.Next(); row++ {
+		values, err := rows.SliceScan()
+		if err != nil {
+			return err
+		}
+		old := reflect.New(modelType)
+		if err := fillValue(old.Interface(), columns, values, row, w.options); err != nil {
+			return err
+		}
+		key, err := w.keyOf(old, keyColumns)
+		if err != nil {
+			return err
+		}
+		if olds[key], err = takeSnapshot(old, w.options); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// auditInsert record every column of inserted models
+func (w writeModel) auditInsert(ctx context.Context, exec sqlx.ExtContext, elems []reflect.Value) error {
+	return w.auditWrite(ctx, exec, elems, nil, nil)
+}
+
+// auditUpdate record changed column of updated models, model without change is skipped
+func (w writeModel) auditUpdate(ctx context.Context, exec sqlx.ExtContext, elems []reflect.Value, olds map[string]map[string]interface{}) error {
+	return w.auditWrite(ctx, exec, elems, w.pkColumns(), olds)
+}
+
+/*
+auditWrite record insert when old row of key is not found and update diff when it is found,
+keyColumns is columns of olds key
+*/
+func (w writeModel) auditWrite(ctx context.Context, exec sqlx.ExtContext, elems []reflect.Value, keyColumns []writeColumn, olds map[string]map[string]interface{}) error {
+	if !w.audit {
+		return nil
+	}
+	var records = make([]auditRecord, 0, len(elems))
+	for _, elem := range elems {
+		recordID, err := w.keyOf(elem, w.pkColumns())
+		if err != nil {
+			return err
+		}
+		snapshot, err := takeSnapshot(elem, w.options)
+		if err != nil {
+			return err
+		}
+		var old map[string]interface{}
+		if len(keyColumns) > 0 {
+			key, err := w.keyOf(elem, keyColumns)
+			if err != nil {
+				return err
+			}
+			old = olds[key]
+		}
+		if old == nil {
+			records = append(records, auditRecord{recordID: recordID, action: AUDIT_ACTION_INSERT, new: snapshot})
+			continue
+		}
+
+		meta, err := getModelMeta(elem.Type())
+		if err != nil {
+			return err
+		}
+		changes := diffSnapshot(meta, old, snapshot)
+		if len(changes) == 0 {
+			continue
+		}
+		var record = auditRecord{recordID: recordID, action: AUDIT_ACTION_UPDATE, old: make(map[string]interface{}, len(changes)), new: make(map[string]interface{}, len(changes))}
+		for _, change := range changes {
+			record.old[change.Column] = change.Old
+			record.new[change.Column] = change.New
+		}
+		records = append(records, record)
+	}
+	return w.insertAudit(ctx, exec, records)
+}
+
+// auditDelete record old row of deleted models, model which row is not found is skipped
+func (w writeModel) auditDelete(ctx context.Context, exec sqlx.ExtContext, elems []reflect.Value, olds map[string]map[string]interface{}) error {
+	if !w.audit {
+		return nil
+	}
+	var records = make([]auditRecord, 0, len(elems))
+	var seen = make(map[string]bool, len(elems))
+	for _, elem := range elems {
+		recordID, err := w.keyOf(elem, w.pkColumns())
+		if err != nil {
+			return err
+		}
+		if old, ok := olds[recordID]; ok && !seen[recordID] {
+			seen[recordID] = true
+			records = append(records, auditRecord{recordID: recordID, action: AUDIT_ACTION_DELETE, old: old})
+		}
+	}
+	return w.insertAudit(ctx, exec, records)
+}
+
+// insertAudit write records into AUDIT_TABLE_NAME with the same transaction of model (auditTx)
+func (w writeModel) insertAudit(ctx context.Context, exec sqlx.ExtContext, records []auditRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	var actor, requestID interface{}
+	if val := AuditActor(ctx); val != "" {
+		actor = val
+	}
+	if val := AuditRequestID(ctx); val != "" {
+		requestID = val
+	}
+	var createdAt = now()
+
+	chunkSize := maxBindParameters / len(auditColumns)
+	for start := 0; start < len(records); start += chunkSize {
+		end := start + chunkSize
+		if end > len(records) {
+			end = len(records)
+		}
+		var args = make([]interface{}, 0, (end-start)*len(auditColumns))
+		var rows = make([]string, 0, end-start)
+		for _, record := range records[start:end] {
+			oldValues, err := encodeAuditValues(record.old)
+			if err != nil {
+				return err
+			}
+			newValues, err := encodeAuditValues(record.new)
+			if err != nil {
+				return err
+			}
+			args = append(args, w.table, record.recordID, record.action, oldValues, newValues, actor, requestID, createdAt)
+			var placeholders = make([]string, 0, len(auditColumns))
+			for index := len(args) - len(auditColumns); index < len(args); index++ {
+				placeholders = append(placeholders, fmt.Sprintf("$%d", index+1))
+			}
+			rows = append(rows, "("+strings.Join(placeholders, ",")+")")
+		}
+
+		var columns = make([]string, 0, len(auditColumns))
+		for _, column := range auditColumns {
+			columns = append(columns, quoteIdentifier(column))
+		}
+		query := fmt.Sprintf(`INSERT INTO %s (%s) VALUES %s`, quoteIdentifier(AUDIT_TABLE_NAME), strings.Join(columns, ","), strings.Join(rows, ","))
+		if _, err := exec.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeAuditValues json of column values, []byte is written as string. nil values is NULL
+func encodeAuditValues(values map[string]interface{}) (interface{}, error) {
+	if values == nil {
+		return nil, nil
+	}
+	var encoded = make(map[string]interface{}, len(values))
+	for column, val := range values {
+		if bu, ok := val.([]byte); ok {
+			val = string(bu)
+		}
+		encoded[column] = val
+	}
+	bu, err := json.Marshal(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return string(bu), nil
+}
@@ -0,0 +1,142 @@
+package orm_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/Pheethy/psql/orm"
+	"github.com/Pheethy/sqlx"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v2"
+)
+
+type Payment struct {
+	TableName struct{} `json:"-" db:"payments" pk:"ID" audit:"true"`
+	ID        int      `json:"id" db:"id"`
+	Amount    float64  `json:"amount" db:"amount"`
+	Status    string   `json:"status" db:"status"`
+}
+
+func TestAudit(t *testing.T) {
+	var columns = []string{"id", "amount", "status"}
+	var auditSQL = `INSERT INTO "audit_logs" ("table_name","record_id","action","old_values","new_values","actor","request_id","created_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`
+	var selectSQL = `SELECT "id","amount","status" FROM "payments" WHERE ("id") IN (($1)) FOR UPDATE`
+	var ctx = orm.WithAuditRequestID(orm.WithAuditActor(context.Background(), "user-1"), "req-1")
+
+	t.Run("success_insert_audit_begin_commit", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		dbmock.ExpectBegin()
+		dbmock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "payments"`)).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 100.0, "pending"))
+		dbmock.ExpectExec(regexp.QuoteMeta(auditSQL)).
+			WithArgs("payments", "1", "insert", nil, `{"amount":100,"id":1,"status":"pending"}`, "user-1", "req-1", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		dbmock.ExpectCommit()
+
+		err := orm.Insert(ctx, db, &Payment{Amount: 100, Status: "pending"})
+		assert.NoError(t, err)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("error_audit_insert_rollback", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		errAudit := errors.New("audit_logs is not writable")
+		dbmock.ExpectBegin()
+		dbmock.ExpectQuery(regexp.QuoteMeta(selectSQL)).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 100.0, "pending"))
+		dbmock.ExpectQuery(regexp.QuoteMeta(`UPDATE "payments"`)).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 100.0, "paid"))
+		dbmock.ExpectExec(regexp.QuoteMeta(auditSQL)).WillReturnError(errAudit)
+		dbmock.ExpectRollback()
+
+		err := orm.Update(ctx, db, &Payment{ID: 1, Amount: 100, Status: "paid"})
+		assert.ErrorIs(t, err, errAudit)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("error_exec_without_transaction", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		/* *sqlx.Conn and *sqlx.DB can begin, other exec is rejected before write */
+		var exec sqlx.ExtContext = struct{ sqlx.ExtContext }{db}
+
+		err := orm.Insert(ctx, exec, &Payment{Amount: 100, Status: "pending"})
+		assert.ErrorIs(t, err, orm.ErrAuditTransaction)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("success_update_audit_in_transaction", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		dbmock.ExpectBegin()
+		dbmock.ExpectQuery(regexp.QuoteMeta(selectSQL)).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 100.0, "pending"))
+		dbmock.ExpectQuery(regexp.QuoteMeta(`UPDATE "payments"`)).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 100.0, "paid"))
+		dbmock.ExpectExec(regexp.QuoteMeta(auditSQL)).
+			WithArgs("payments", "1", "update", `{"status":"pending"}`, `{"status":"paid"}`, "user-1", "req-1", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		dbmock.ExpectCommit()
+
+		tx, err := db.Beginx()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = orm.Update(ctx, tx, &Payment{ID: 1, Amount: 100, Status: "paid"})
+		assert.NoError(t, err)
+		assert.NoError(t, tx.Commit())
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("success_delete_audit_without_actor", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		dbmock.ExpectBegin()
+		dbmock.ExpectQuery(regexp.QuoteMeta(selectSQL)).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 100.0, "paid"))
+		dbmock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "payments" WHERE ("id") IN (($1))`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		dbmock.ExpectExec(regexp.QuoteMeta(auditSQL)).
+			WithArgs("payments", "1", "delete", `{"amount":100,"id":1,"status":"paid"}`, nil, nil, nil, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		dbmock.ExpectCommit()
+
+		err := orm.Delete(context.Background(), db, &Payment{ID: 1})
+		assert.NoError(t, err)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("success_upsert_audit_insert_when_old_row_not_found", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		dbmock.ExpectBegin()
+		dbmock.ExpectQuery(regexp.QuoteMeta(selectSQL)).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows(columns))
+		dbmock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "payments"`)).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(2, 50.0, "pending"))
+		dbmock.ExpectExec(regexp.QuoteMeta(auditSQL)).
+			WithArgs("payments", "2", "insert", nil, `{"amount":50,"id":2,"status":"pending"}`, "user-1", "req-1", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		dbmock.ExpectCommit()
+
+		err := orm.Upsert(ctx, db, &Payment{ID: 2, Amount: 50, Status: "pending"}, orm.NewUpsertOption())
+		assert.NoError(t, err)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("success_update_without_change_skip_audit", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		dbmock.ExpectBegin()
+		dbmock.ExpectQuery(regexp.QuoteMeta(selectSQL)).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 100.0, "paid"))
+		dbmock.ExpectQuery(regexp.QuoteMeta(`UPDATE "payments"`)).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, 100.0, "paid"))
+		dbmock.ExpectCommit()
+
+		err := orm.Update(ctx, db, &Payment{ID: 1, Amount: 100, Status: "paid"})
+		assert.NoError(t, err)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,156 @@
+package orm
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Pheethy/psql/helper"
+	"github.com/fatih/structs"
+)
+
+var TAG_AUTO_CREATE_TIME = "autoCreateTime"
+var TAG_AUTO_UPDATE_TIME = "autoUpdateTime"
+var TAG_VERSION = "version"
+
+var timestampType = reflect.TypeOf(helper.Timestamp{})
+
+func isTagEnabled(field reflect.StructField, tag string) bool {
+	enabled, _ := strconv.ParseBool(strings.TrimSpace(field.Tag.Get(tag)))
+	return enabled
+}
+
+// isAutoTimeType auto time field must be time.Time or helper.Timestamp (or pointer)
+func isAutoTimeType(fieldType reflect.Type) bool {
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	return fieldType == timeType || fieldType == timestampType
+}
+
+func isVersionType(fieldType reflect.Type) bool {
+	switch fieldType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// now in helper.TimeLocation, same timezone as helper.Timestamp
+func now() time.Time {
+	return time.Now().In(helper.TimeLocation)
+}
+
+/*
+stampInsert fill zero autoCreateTime, autoUpdateTime with now
+and zero version with 1 before INSERT
+*/
+func (w writeModel) stampInsert(elems []reflect.Value) error {
+	var createdAt = now()
+	for row, elem := range elems {
+		for _, field := range []*fieldMeta{w.createTime, w.updateTime} {
+			if field != nil && field.isZero(elem) {
+				if err := w.setField(elem, row, field, createdAt); err != nil {
+					return err
+				}
+			}
+		}
+		if w.version != nil && w.version.isZero(elem) {
+			if err := w.setField(elem, row, w.version, int64(1)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// stampUpdate set autoUpdateTime with now before UPDATE
+func (w writeModel) stampUpdate(elems []reflect.Value) error {
+	if w.updateTime == nil {
+		return nil
+	}
+	var updatedAt = now()
+	for row, elem := range elems {
+		if err := w.setField(elem, row, w.updateTime, updatedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+fieldBackup is value of field before stampUpdate and RETURNING,
+pointee is copied too because registry may bind into the same pointer
+*/
+type fieldBackup struct {
+	elem    reflect.Value
+	field   *fieldMeta
+	value   reflect.Value
+	pointee reflect.Value
+}
+
+// backupStamp copy autoUpdateTime and version of models, restore with restoreFields when UPDATE is failed
+func (w writeModel) backupStamp(elems []reflect.Value) []fieldBackup {
+	return backupFields(elems, w.updateTime, w.version)
+}
+
+// backupFields copy fields of models, nil field is skipped
+func backupFields(elems []reflect.Value, fields ...*fieldMeta) []fieldBackup {
+	var backups = make([]fieldBackup, 0, len(elems)*len(fields))
+	for _, elem := range elems {
+		for _, field := range fields {
+			if field == nil {
+				continue
+			}
+			val, err := reflect.Indirect(elem).FieldByIndexErr(field.index)
+			if err != nil {
+				/* nil embedded pointer, field is zero */
+				backups = append(backups, fieldBackup{elem: elem, field: field})
+				continue
+			}
+			var backup = fieldBackup{elem: elem, field: field, value: reflect.New(val.Type()).Elem()}
+			backup.value.Set(val)
+			if val.Kind() == reflect.Ptr && !val.IsNil() {
+				backup.pointee = reflect.New(val.Type().Elem()).Elem()
+				backup.pointee.Set(val.Elem())
+			}
+			backups = append(backups, backup)
+		}
+	}
+	return backups
+}
+
+func restoreFields(backups []fieldBackup) {
+	for _, backup := range backups {
+		val, err := reflect.Indirect(backup.elem).FieldByIndexErr(backup.field.index)
+		if err != nil {
+			continue
+		}
+		if !backup.value.IsValid() {
+			val.Set(reflect.Zero(val.Type()))
+			continue
+		}
+		val.Set(backup.value)
+		if backup.pointee.IsValid() {
+			val.Elem().Set(backup.pointee)
+		}
+	}
+}
+
+func (w writeModel) setField(elem reflect.Value, row int, field *fieldMeta, val interface{}) error {
+	if err := bindField(structs.New(elem.Interface()), elem, field, val, w.options); err != nil {
+		return &MappingError{Model: elem.Type().Elem().String(), Field: field.name, Column: field.column, Row: row, Value: val, Err: err}
+	}
+	return nil
+}
+
+// isZero nil pointer and zero value is zero, field of nil embedded pointer too
+func (f *fieldMeta) isZero(elem reflect.Value) bool {
+	val, ok := f.lookup(elem)
+	if !ok || isNil(val) {
+		return true
+	}
+	return reflect.Indirect(reflect.ValueOf(val)).IsZero()
+}
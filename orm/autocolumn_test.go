@@ -0,0 +1,155 @@
+package orm_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"regexp"
+	"testing"
+	"time"
+
+	helperModel "github.com/Pheethy/psql/helper"
+	"github.com/Pheethy/psql/orm"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v2"
+)
+
+type Contract struct {
+	TableName struct{}               `json:"-" db:"contracts" pk:"ID"`
+	ID        int                    `json:"id" db:"id"`
+	Amount    float64                `json:"amount" db:"amount"`
+	CreatedAt *helperModel.Timestamp `json:"created_at" db:"created_at" autoCreateTime:"true"`
+	UpdatedAt *time.Time             `json:"updated_at" db:"updated_at" autoUpdateTime:"true"`
+	Version   int                    `json:"version" db:"version" version:"true"`
+}
+
+type InvalidVersionContract struct {
+	TableName struct{} `json:"-" db:"contracts" pk:"ID"`
+	ID        int      `json:"id" db:"id"`
+	CreatedAt string   `json:"created_at" db:"created_at" autoCreateTime:"true"`
+	Version   string   `json:"version" db:"version" version:"true"`
+}
+
+// inLocation match time.Time argument in location
+type inLocation struct {
+	location *time.Location
+}
+
+func (a inLocation) Match(v driver.Value) bool {
+	val, ok := v.(time.Time)
+	return ok && val.Location() == a.location
+}
+
+func TestAutoColumn(t *testing.T) {
+	var returningColumns = []string{"id", "amount", "created_at", "updated_at", "version"}
+	var returning = `RETURNING "id","amount","created_at","updated_at","version"`
+	var createdAt = time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("success_insert_fill_time_and_version", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		contract := &Contract{Amount: 100}
+		sql := `INSERT INTO "contracts" ("id","amount","created_at","updated_at","version") VALUES (DEFAULT,$1,$2,$3,$4) ` + returning
+		dbmock.ExpectQuery(regexp.QuoteMeta(sql)).
+			WithArgs(100.0, sqlmock.AnyArg(), sqlmock.AnyArg(), 1).
+			WillReturnRows(sqlmock.NewRows(returningColumns).AddRow(1, 100.0, "2023-06-01 10:00:00", createdAt, 1))
+
+		err := orm.Insert(context.Background(), db, contract)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, contract.ID)
+		assert.Equal(t, 1, contract.Version)
+		assert.NotNil(t, contract.CreatedAt)
+		assert.NotNil(t, contract.UpdatedAt)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("success_update_time_in_configured_location", func(t *testing.T) {
+		location := helperModel.TimeLocation
+		helperModel.TimeLocation = time.UTC
+		defer func() { helperModel.TimeLocation = location }()
+
+		db, dbmock := newDB(t)
+		contract := &Contract{ID: 1, Amount: 200, Version: 3}
+		sql := `UPDATE "contracts" SET "amount" = $1,"updated_at" = $2,"version" = "version" + 1 WHERE "id" = $3 AND "version" = $4 ` + returning
+		dbmock.ExpectQuery(regexp.QuoteMeta(sql)).
+			WithArgs(200.0, inLocation{time.UTC}, 1, 3).
+			WillReturnRows(sqlmock.NewRows(returningColumns).AddRow(1, 200.0, "2023-06-01 10:00:00", createdAt, 4))
+
+		err := orm.Update(context.Background(), db, contract, "amount")
+		assert.NoError(t, err)
+		assert.Equal(t, 4, contract.Version)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("success_update_default_columns_keep_create_time", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		contract := &Contract{ID: 1, Amount: 200, Version: 3}
+		sql := `UPDATE "contracts" SET "amount" = $1,"updated_at" = $2,"version" = "version" + 1 WHERE "id" = $3 AND "version" = $4 ` + returning
+		dbmock.ExpectQuery(regexp.QuoteMeta(sql)).
+			WithArgs(200.0, sqlmock.AnyArg(), 1, 3).
+			WillReturnRows(sqlmock.NewRows(returningColumns).AddRow(1, 200.0, "2023-06-01 10:00:00", createdAt, 4))
+
+		/* created_at is not set by default columns */
+		err := orm.Update(context.Background(), db, contract)
+		assert.NoError(t, err)
+		assert.NotNil(t, contract.CreatedAt)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("success_restore_update_time_when_failed", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		updatedAt := createdAt
+		contracts := []*Contract{{ID: 1, Amount: 200, Version: 3}, {ID: 2, Amount: 300, UpdatedAt: &updatedAt, Version: 5}}
+		dbmock.ExpectQuery(regexp.QuoteMeta(`UPDATE "contracts"`)).
+			WithArgs(200.0, sqlmock.AnyArg(), 1, 3).
+			WillReturnRows(sqlmock.NewRows(returningColumns).AddRow(1, 200.0, "2023-06-01 10:00:00", createdAt, 4))
+		dbmock.ExpectQuery(regexp.QuoteMeta(`UPDATE "contracts"`)).
+			WithArgs(300.0, sqlmock.AnyArg(), 2, 5).
+			WillReturnRows(sqlmock.NewRows(returningColumns))
+
+		/* second model is stale, first model is written */
+		err := orm.UpdateBatch(context.Background(), db, contracts, "amount")
+		assert.ErrorIs(t, err, orm.ErrStaleObject)
+		assert.Equal(t, 4, contracts[0].Version)
+		assert.NotNil(t, contracts[0].UpdatedAt)
+		if assert.NotNil(t, contracts[1].UpdatedAt) {
+			assert.Equal(t, createdAt, *contracts[1].UpdatedAt)
+		}
+		assert.Equal(t, 5, contracts[1].Version)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+
+		/* statement error before any model is written */
+		contract := &Contract{ID: 1, Amount: 200, Version: 3}
+		assert.Error(t, orm.Update(context.Background(), db, contract, "amount"))
+		assert.Nil(t, contract.UpdatedAt)
+	})
+
+	t.Run("error_stale_object", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		contract := &Contract{ID: 1, Amount: 200, Version: 3}
+		dbmock.ExpectQuery(regexp.QuoteMeta(`UPDATE "contracts"`)).
+			WillReturnRows(sqlmock.NewRows(returningColumns))
+
+		err := orm.Update(context.Background(), db, contract)
+		assert.ErrorIs(t, err, orm.ErrStaleObject)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("success_upsert_increment_version", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		contract := &Contract{ID: 1, Amount: 300}
+		sql := `INSERT INTO "contracts" ("id","amount","created_at","updated_at","version") VALUES ($1,$2,$3,$4,$5) ` +
+			`ON CONFLICT ("id") DO UPDATE SET "amount" = EXCLUDED."amount","updated_at" = EXCLUDED."updated_at","version" = "contracts"."version" + 1 ` + returning
+		dbmock.ExpectQuery(regexp.QuoteMeta(sql)).
+			WillReturnRows(sqlmock.NewRows(returningColumns).AddRow(1, 300.0, "2023-06-01 10:00:00", createdAt, 5))
+
+		err := orm.Upsert(context.Background(), db, contract, orm.NewUpsertOption())
+		assert.NoError(t, err)
+		assert.Equal(t, 5, contract.Version)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("error_invalid_tag_type", func(t *testing.T) {
+		err := orm.ValidateModels(new(InvalidVersionContract))
+		assert.ErrorIs(t, err, orm.ErrInvalidAutoTime)
+		assert.ErrorIs(t, err, orm.ErrInvalidVersion)
+	})
+}
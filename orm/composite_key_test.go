@@ -0,0 +1,135 @@
+package orm_test
+
+import (
+	"testing"
+
+	"github.com/Pheethy/psql/orm"
+	"github.com/Pheethy/sqlx"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v2"
+)
+
+type SalesOrder struct {
+	TableName struct{}          `json:"-" db:"sales_orders" pk:"No,Branch"`
+	No        int               `json:"no" db:"no" type:"int32"`
+	Branch    int               `json:"branch" db:"branch" type:"int32"`
+	Lines     []*SalesOrderLine `json:"lines" db:"-" fk:"fk_field1:No+Branch,fk_field2:OrderNo+Branch"`
+}
+
+type SalesOrderLine struct {
+	TableName struct{} `json:"-" db:"sales_order_lines" pk:"OrderNo,Branch,LineNo,IsGift"`
+	OrderNo   int      `json:"order_no" db:"order_no"`
+	Branch    int      `json:"branch" db:"branch"`
+	LineNo    int      `json:"line_no" db:"line_no"`
+	IsGift    bool     `json:"is_gift" db:"is_gift"`
+	Qty       int      `json:"qty" db:"qty"`
+}
+
+func TestCompositeKey(t *testing.T) {
+	var columns = []string{
+		"sales_orders.no", "sales_orders.branch",
+		"sales_order_lines.order_no", "sales_order_lines.branch", "sales_order_lines.line_no", "sales_order_lines.is_gift", "sales_order_lines.qty",
+	}
+	var newRows = func(t *testing.T, rows *sqlmock.Rows) *sqlx.Rows {
+		db, dbmock, err := sqlmock.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		dbmock.ExpectQuery(`SELECT (.+) sales_orders`).WillReturnRows(rows)
+		sqlxRows, err := sqlx.NewDb(db, "sqlmock").Queryx(`SELECT * FROM sales_orders`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { sqlxRows.Close() })
+		return sqlxRows
+	}
+
+	t.Run("success_zero_value_component_is_key", func(t *testing.T) {
+		rows := newRows(t, sqlmock.NewRows(columns).
+			AddRow(1, 0, 1, 0, 0, false, 5).
+			AddRow(1, 0, 1, 0, 0, true, 1).
+			AddRow(1, 0, 1, 0, 1, false, 2).
+			AddRow(1, 0, 1, 0, 0, false, 5).
+			AddRow(1, 1, 1, 1, 0, false, 7).
+			AddRow(0, 0, 0, 0, 0, false, 9))
+
+		mapper, err := orm.Orm(new(SalesOrder), rows, orm.NewMapperOption())
+		assert.NoError(t, err)
+
+		orders := mapper.GetData().([]*SalesOrder)
+		assert.Len(t, orders, 3)
+		assert.Equal(t, []int{1, 0}, []int{orders[0].No, orders[0].Branch})
+		assert.Len(t, orders[0].Lines, 3)
+		assert.Equal(t, 0, orders[0].Lines[0].LineNo)
+		assert.False(t, orders[0].Lines[0].IsGift)
+		assert.True(t, orders[0].Lines[1].IsGift)
+		assert.Equal(t, 1, orders[0].Lines[2].LineNo)
+
+		/* same No, other Branch is other order and does not join line of branch 0 */
+		assert.Equal(t, []int{1, 1}, []int{orders[1].No, orders[1].Branch})
+		assert.Len(t, orders[1].Lines, 1)
+		assert.Equal(t, 7, orders[1].Lines[0].Qty)
+
+		/* every component is zero */
+		assert.Equal(t, []int{0, 0}, []int{orders[2].No, orders[2].Branch})
+		assert.Len(t, orders[2].Lines, 1)
+		assert.Equal(t, 9, orders[2].Lines[0].Qty)
+	})
+
+	t.Run("success_null_component_is_absent", func(t *testing.T) {
+		rows := newRows(t, sqlmock.NewRows(columns).
+			AddRow(2, 0, nil, nil, nil, nil, nil).
+			AddRow(3, 0, 3, 0, nil, false, 1).
+			AddRow(nil, 0, nil, nil, nil, nil, nil))
+
+		mapper, err := orm.Orm(new(SalesOrder), rows, orm.NewMapperOption())
+		assert.NoError(t, err)
+
+		orders := mapper.GetData().([]*SalesOrder)
+		assert.Len(t, orders, 2)
+		assert.Equal(t, 2, orders[0].No)
+		assert.Len(t, orders[0].Lines, 0)
+		/* line_no is NULL, line is absent */
+		assert.Equal(t, 3, orders[1].No)
+		assert.Len(t, orders[1].Lines, 0)
+	})
+}
+
+type Member struct {
+	TableName struct{} `json:"-" db:"members" pk:"ID"`
+	ID        int      `json:"id" db:"id"`
+	TeamCode  string   `json:"team_code" db:"team_code"`
+	Team      *Team    `json:"team" db:"-" fk:"fk_field1:TeamCode,fk_field2:Code"`
+}
+
+type Team struct {
+	TableName struct{} `json:"-" db:"teams" pk:"Code"`
+	Code      string   `json:"code" db:"code"`
+	Name      string   `json:"name" db:"name"`
+}
+
+func TestNullForeignKey(t *testing.T) {
+	t.Run("success_null_fk_not_join_empty_key", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		dbmock.ExpectQuery(`SELECT (.+) members`).WillReturnRows(sqlmock.NewRows([]string{"members.id", "members.team_code", "teams.code", "teams.name"}).
+			AddRow(1, "", "", "Unassigned").
+			AddRow(2, nil, nil, nil))
+		rows, err := db.Queryx(`SELECT * FROM members`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		mapper, err := orm.Orm(new(Member), rows, orm.NewMapperOption())
+		assert.NoError(t, err)
+		members := mapper.GetData().([]*Member)
+		assert.Len(t, members, 2)
+		if assert.NotNil(t, members[0].Team) {
+			assert.Equal(t, "Unassigned", members[0].Team.Name)
+		}
+		/* team_code is NULL, bound as "" but must not join team of code "" */
+		assert.Equal(t, "", members[1].TeamCode)
+		assert.Nil(t, members[1].Team)
+	})
+}
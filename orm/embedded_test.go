@@ -0,0 +1,110 @@
+package orm_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/Pheethy/psql/orm"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v2"
+)
+
+type BaseModel struct {
+	ID        int        `json:"id" db:"id"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at" db:"deleted_at"`
+}
+
+type AuditModel struct {
+	CreatedBy string `json:"created_by" db:"created_by"`
+	UpdatedBy string `json:"updated_by" db:"updated_by"`
+}
+
+type Product struct {
+	TableName struct{} `json:"-" db:"products" pk:"ID"`
+	BaseModel
+	*AuditModel `prefix:"audit_"`
+	Name        string `json:"name" db:"name"`
+}
+
+type ShadowProduct struct {
+	TableName struct{} `json:"-" db:"products" pk:"ID"`
+	BaseModel
+	ID string `json:"id" db:"code"`
+}
+
+func TestEmbedded(t *testing.T) {
+	t.Run("success_selector_flatten_embedded_with_prefix", func(t *testing.T) {
+		assert.Equal(t,
+			`products.id "products.id",products.created_at "products.created_at",products.updated_at "products.updated_at",`+
+				`products.deleted_at "products.deleted_at",products.audit_created_by "products.audit_created_by",`+
+				`products.audit_updated_by "products.audit_updated_by",products.name "products.name"`,
+			orm.GetSelector(new(Product)),
+		)
+		/* outer field shadow embedded field of the same name */
+		assert.Equal(t,
+			`products.created_at "products.created_at",products.updated_at "products.updated_at",`+
+				`products.deleted_at "products.deleted_at",products.code "products.code"`,
+			orm.GetSelector(new(ShadowProduct)),
+		)
+		assert.NoError(t, orm.ValidateModels(new(Product), new(ShadowProduct)))
+	})
+
+	t.Run("success_map_embedded_value_and_pointer", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		dbmock.ExpectQuery(`SELECT (.+) products`).WillReturnRows(
+			sqlmock.NewRows([]string{
+				"products.id", "products.created_at", "products.updated_at", "products.deleted_at",
+				"products.audit_created_by", "products.audit_updated_by", "products.name",
+			}).
+				AddRow(1, "2023-06-01 10:00:00", "2023-06-02 10:00:00", nil, "alice", "bob", "Cake").
+				AddRow(1, "2023-06-01 10:00:00", "2023-06-02 10:00:00", nil, "alice", "bob", "Cake").
+				AddRow(2, "2023-06-03 10:00:00", "2023-06-03 10:00:00", "2023-06-04 10:00:00", "carol", "carol", "Donut"),
+		)
+		rows, err := db.Queryx(`SELECT * FROM products`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		mapper, err := orm.Orm(new(Product), rows, orm.NewMapperOption())
+		assert.NoError(t, err)
+
+		products := mapper.GetData().([]*Product)
+		assert.Len(t, products, 2)
+		assert.Equal(t, 1, products[0].ID)
+		assert.Equal(t, "2023-06-01 10:00:00", products[0].CreatedAt.Format("2006-01-02 15:04:05"))
+		assert.Nil(t, products[0].DeletedAt)
+		assert.NotNil(t, products[0].AuditModel)
+		assert.Equal(t, "alice", products[0].CreatedBy)
+		assert.Equal(t, "Cake", products[0].Name)
+		assert.Equal(t, 2, products[1].ID)
+		assert.NotNil(t, products[1].DeletedAt)
+		assert.Equal(t, "carol", products[1].UpdatedBy)
+		/* every row has its own embedded pointer */
+		assert.NotSame(t, products[0].AuditModel, products[1].AuditModel)
+	})
+
+	t.Run("success_insert_embedded_columns", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		createdAt := time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+		product := &Product{BaseModel: BaseModel{CreatedAt: createdAt, UpdatedAt: createdAt}, Name: "Cake"}
+
+		sql := `INSERT INTO "products" ("id","created_at","updated_at","deleted_at","audit_created_by","audit_updated_by","name") ` +
+			`VALUES (DEFAULT,$1,$2,$3,DEFAULT,DEFAULT,$4) ` +
+			`RETURNING "id","created_at","updated_at","deleted_at","audit_created_by","audit_updated_by","name"`
+		dbmock.ExpectQuery(regexp.QuoteMeta(sql)).
+			WithArgs(createdAt, createdAt, nil, "Cake").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at", "deleted_at", "audit_created_by", "audit_updated_by", "name"}).
+				AddRow(7, createdAt, createdAt, nil, "system", "system", "Cake"))
+
+		err := orm.Insert(context.Background(), db, product)
+		assert.NoError(t, err)
+		assert.Equal(t, 7, product.ID)
+		assert.Equal(t, "system", product.CreatedBy)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+}
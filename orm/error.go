@@ -1,12 +1,112 @@
 package orm
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
 var (
 	ErrMustNotNil         = errors.New("data model must not be nil")
 	ErrMustBeStruct       = errors.New("data value must be type struct")
+	ErrMustBeSlice        = errors.New("data models must be slice of the same model pointer")
 	ErrFieldNotFound      = errors.New("field not found")
+	ErrColumnNotFound     = errors.New("column not found")
 	ErrTagValueNotFound   = errors.New("tag value not found")
 	ErrNotIdentifyFkField = errors.New("not identify fk field on tag")
 	ErrRegistryNotFound   = errors.New("registry not found")
+	ErrRegistryDuplicate  = errors.New("registry already exists")
+	ErrInvalidRegistry    = errors.New("invalid registry")
+	ErrTableNameNotFound  = errors.New("table name not found on TableName tag")
+	ErrPrimaryKeyNotFound = errors.New("primary key not found on TableName tag")
+	ErrPrimaryKeyIsEmpty  = errors.New("primary key value must not be empty")
+	ErrNoColumnToUpdate   = errors.New("no column to update")
+	ErrInvalidOrderTag    = errors.New("invalid order tag")
+	ErrUnmappedColumn     = errors.New("column is not mapped to any field")
+	ErrUnmappedField      = errors.New("field is not mapped from any column")
+	ErrIncompatibleColumn = errors.New("column type is incompatible with field type")
+	ErrIncompatibleFkType = errors.New("fk fields have incompatible registry type")
+	ErrDuplicateColumn    = errors.New("duplicate db column")
+	ErrInvalidAlias       = errors.New("invalid alias on fk tag")
+	ErrDuplicateAlias     = errors.New("duplicate alias on fk tag")
+	ErrInvalidPaginate    = errors.New("page and per page must be greater than 0")
+	ErrInvalidCursor      = errors.New("invalid cursor")
+	ErrInvalidSoftDelete  = errors.New("invalid softdelete tag")
+	ErrInvalidAutoTime    = errors.New("invalid autoCreateTime or autoUpdateTime tag")
+	ErrInvalidVersion     = errors.New("invalid version tag")
+	ErrStaleObject        = errors.New("stale object, version is changed or row is deleted")
+	ErrModelNotTracked    = errors.New("model is not tracked")
+	ErrDuplicateConflict  = errors.New("duplicate conflict target value in upsert batch")
+	ErrAuditTransaction   = errors.New("audit model must be written with *sqlx.DB or *sqlx.Tx")
+	ErrSoftDeletedRoot    = errors.New("soft deleted root is filtered after total_row, use SoftDeleteCondition in query")
 )
+
+/*
+MappingError is error while map rows into model, use with errors.As
+Row is index of rows start from 0, -1 when error is not from a row
+*/
+type MappingError struct {
+	Model  string      // model type, example models.Order
+	Field  string      // go field name
+	Column string      // column name of rows
+	Row    int         // row index
+	Value  interface{} // raw value of column
+	Err    error       // underlying cause
+}
+
+func (e *MappingError) Error() string {
+	var context = make([]string, 0, 4)
+	if e.Model != "" {
+		if e.Field != "" {
+			context = append(context, "field "+e.Model+"."+e.Field)
+		} else {
+			context = append(context, "model "+e.Model)
+		}
+	} else if e.Field != "" {
+		context = append(context, "field "+e.Field)
+	}
+	if e.Column != "" {
+		context = append(context, fmt.Sprintf("column %q", e.Column))
+	}
+	if e.Row >= 0 {
+		context = append(context, fmt.Sprintf("row %d", e.Row))
+	}
+	if e.Value != nil {
+		context = append(context, fmt.Sprintf("value %s", formatMappingValue(e.Value)))
+	}
+	return fmt.Sprintf("orm: mapping %s: %v", strings.Join(context, ", "), e.Err)
+}
+
+func (e *MappingError) Unwrap() error {
+	return e.Err
+}
+
+/*
+HookError is error or panic from lifecycle hook of model, use with errors.As
+Index is position of model in batch (or in relation slice for AfterFind)
+*/
+type HookError struct {
+	Model string // model type, example models.Order
+	Hook  string // hook name, example BeforeInsert
+	Index int
+	Err   error
+}
+
+func (e *HookError) Error() string {
+	return fmt.Sprintf("orm: hook %s of model %s, index %d: %v", e.Hook, e.Model, e.Index, e.Err)
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+func formatMappingValue(val interface{}) string {
+	if bu, ok := val.([]byte); ok {
+		val = string(bu)
+	}
+	text := fmt.Sprintf("%v", val)
+	if len(text) > 64 {
+		text = text[:64] + "..."
+	}
+	return fmt.Sprintf("%q", text)
+}
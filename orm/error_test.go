@@ -0,0 +1,84 @@
+package orm_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/Pheethy/psql/orm"
+	"github.com/Pheethy/sqlx"
+	"github.com/fatih/structs"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v2"
+)
+
+type panicRegistry struct{}
+
+func (panicRegistry) TypeName() string                        { return "panic" }
+func (panicRegistry) RegisterPkId(val interface{}) string     { return "" }
+func (panicRegistry) Equal(x interface{}, y interface{}) bool { return false }
+func (panicRegistry) Bind(field *structs.Field, val interface{}) error {
+	panic("bind is broken")
+}
+
+type UnknownTypeMenu struct {
+	TableName struct{} `json:"-" db:"menus" pk:"ID"`
+	ID        int      `json:"id" db:"id"`
+	Name      string   `json:"name" db:"name" type:"not_registered"`
+}
+
+func TestMappingError(t *testing.T) {
+	var newRows = func(t *testing.T, rows *sqlmock.Rows) *sqlx.Rows {
+		db, dbmock, err := sqlmock.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		dbmock.ExpectQuery(`SELECT (.+) menus`).WillReturnRows(rows)
+		sqlxRows, err := sqlx.NewDb(db, "sqlmock").Queryx(`SELECT * FROM menus`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { sqlxRows.Close() })
+		return sqlxRows
+	}
+
+	t.Run("error_bind_with_row_context", func(t *testing.T) {
+		rows := newRows(t, sqlmock.NewRows([]string{"menus.id", "menus.name"}).
+			AddRow("1", "breakfast").
+			AddRow("two", "lunch"))
+
+		_, err := orm.Orm(new(Menu), rows, orm.NewMapperOption())
+		var mappingErr *orm.MappingError
+		assert.True(t, errors.As(err, &mappingErr))
+		assert.Equal(t, "orm_test.Menu", mappingErr.Model)
+		assert.Equal(t, "ID", mappingErr.Field)
+		assert.Equal(t, "menus.id", mappingErr.Column)
+		assert.Equal(t, 1, mappingErr.Row)
+		assert.Equal(t, "two", mappingErr.Value)
+		assert.ErrorIs(t, err, strconv.ErrSyntax)
+		assert.Contains(t, err.Error(), `field orm_test.Menu.ID, column "menus.id", row 1`)
+	})
+
+	t.Run("error_registry_not_found", func(t *testing.T) {
+		rows := newRows(t, sqlmock.NewRows([]string{"menus.id", "menus.name"}).AddRow(1, "breakfast"))
+
+		_, err := orm.Orm(new(UnknownTypeMenu), rows, orm.NewMapperOption())
+		var mappingErr *orm.MappingError
+		assert.True(t, errors.As(err, &mappingErr))
+		assert.Equal(t, "Name", mappingErr.Field)
+		assert.Equal(t, 0, mappingErr.Row)
+		assert.ErrorIs(t, err, orm.ErrRegistryNotFound)
+	})
+
+	t.Run("error_panic_in_bind", func(t *testing.T) {
+		rows := newRows(t, sqlmock.NewRows([]string{"menus.id", "menus.name"}).AddRow(1, "breakfast"))
+
+		option := orm.NewMapperOption().SetRegistry("not_registered", panicRegistry{})
+		_, err := orm.Orm(new(UnknownTypeMenu), rows, option)
+		var mappingErr *orm.MappingError
+		assert.True(t, errors.As(err, &mappingErr))
+		assert.Equal(t, "Name", mappingErr.Field)
+		assert.Contains(t, err.Error(), "panic: bind is broken")
+	})
+}
@@ -5,6 +5,7 @@ import "strings"
 type foreignKey struct {
 	fkField1 []string
 	fkField2 []string
+	alias    string // column prefix of reference model, `alias.col` instead of `tablename.col`
 }
 
 func newForeignKeyFromTag(tag string) foreignKey {
@@ -13,12 +14,25 @@ func newForeignKeyFromTag(tag string) foreignKey {
 	fkField2 := []string{}
 	var fkKey1 = "fk_field1"
 	var fkKey2 = "fk_field2"
+	var aliasKey = "alias:"
+	var alias string
 	var getFkField = func(fkVal string) []string {
-		data := strings.Split(fkVal, ":")
+		data := strings.SplitN(fkVal, ":", 2)
+		if len(data) != 2 || strings.TrimSpace(data[1]) == "" {
+			return []string{}
+		}
 
-		return strings.Split(data[1], fieldFKSeperate)
+		fields := strings.Split(data[1], fieldFKSeperate)
+		for index := range fields {
+			fields[index] = strings.TrimSpace(fields[index])
+		}
+		return fields
 	}
 	for _, val := range vals {
+		if strings.HasPrefix(strings.TrimSpace(val), aliasKey) {
+			alias = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(val), aliasKey))
+			continue
+		}
 		if strings.Contains(val, fkKey1) {
 			fkField1 = getFkField(val)
 		}
@@ -29,12 +43,16 @@ func newForeignKeyFromTag(tag string) foreignKey {
 	return foreignKey{
 		fkField1: fkField1,
 		fkField2: fkField2,
+		alias:    alias,
 	}
 }
 
 func (f foreignKey) Validate() error {
-	if len(f.fkField1) == 0 || len(f.fkField2) == 0 {
+	if len(f.fkField1) == 0 || len(f.fkField2) == 0 || len(f.fkField1) != len(f.fkField2) {
 		return ErrNotIdentifyFkField
 	}
+	if strings.ContainsAny(f.alias, `." `) {
+		return ErrInvalidAlias
+	}
 	return nil
 }
@@ -0,0 +1,18 @@
+package orm_test
+
+import (
+	"testing"
+
+	"github.com/Pheethy/sqlx"
+	"gopkg.in/DATA-DOG/go-sqlmock.v2"
+)
+
+// newDB stub database of sqlmock, closed when test is done
+func newDB(t *testing.T) (*sqlx.DB, sqlmock.Sqlmock) {
+	db, dbmock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return sqlx.NewDb(db, "sqlmock"), dbmock
+}
@@ -0,0 +1,182 @@
+package orm
+
+import (
+	"context"
+	"reflect"
+)
+
+/*
+lifecycle hook of model, implement on pointer of model.
+error of before hook abort the statement, every hook error is *HookError
+*/
+type BeforeInsertHook interface {
+	BeforeInsert(ctx context.Context) error
+}
+
+type AfterInsertHook interface {
+	AfterInsert(ctx context.Context) error
+}
+
+type BeforeUpdateHook interface {
+	BeforeUpdate(ctx context.Context) error
+}
+
+type AfterUpdateHook interface {
+	AfterUpdate(ctx context.Context) error
+}
+
+type BeforeDeleteHook interface {
+	BeforeDelete(ctx context.Context) error
+}
+
+type AfterDeleteHook interface {
+	AfterDelete(ctx context.Context) error
+}
+
+// AfterFindHook is called by Orm after relation is bound, relation model is called before its parent
+type AfterFindHook interface {
+	AfterFind(ctx context.Context) error
+}
+
+const (
+	hookBeforeInsert = "BeforeInsert"
+	hookAfterInsert  = "AfterInsert"
+	hookBeforeUpdate = "BeforeUpdate"
+	hookAfterUpdate  = "AfterUpdate"
+	hookBeforeDelete = "BeforeDelete"
+	hookAfterDelete  = "AfterDelete"
+	hookAfterFind    = "AfterFind"
+)
+
+var afterFindHookType = reflect.TypeOf((*AfterFindHook)(nil)).Elem()
+
+// callHooks call hook of every model in order, stop at the first error
+func callHooks(ctx context.Context, hook string, elems []reflect.Value) error {
+	for index, elem := range elems {
+		if err := callHook(ctx, hook, elem, index); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func callHook(ctx context.Context, hook string, elem reflect.Value, index int) (err error) {
+	defer func() {
+		if panicErr := recovery(recover()); panicErr != nil {
+			err = &HookError{Model: elem.Type().Elem().String(), Hook: hook, Index: index, Err: panicErr}
+		}
+	}()
+	model := elem.Interface()
+	switch hook {
+	case hookBeforeInsert:
+		if h, ok := model.(BeforeInsertHook); ok {
+			err = h.BeforeInsert(ctx)
+		}
+	case hookAfterInsert:
+		if h, ok := model.(AfterInsertHook); ok {
+			err = h.AfterInsert(ctx)
+		}
+	case hookBeforeUpdate:
+		if h, ok := model.(BeforeUpdateHook); ok {
+			err = h.BeforeUpdate(ctx)
+		}
+	case hookAfterUpdate:
+		if h, ok := model.(AfterUpdateHook); ok {
+			err = h.AfterUpdate(ctx)
+		}
+	case hookBeforeDelete:
+		if h, ok := model.(BeforeDeleteHook); ok {
+			err = h.BeforeDelete(ctx)
+		}
+	case hookAfterDelete:
+		if h, ok := model.(AfterDeleteHook); ok {
+			err = h.AfterDelete(ctx)
+		}
+	case hookAfterFind:
+		if h, ok := model.(AfterFindHook); ok {
+			err = h.AfterFind(ctx)
+		}
+	}
+	if err != nil {
+		return &HookError{Model: elem.Type().Elem().String(), Hook: hook, Index: index, Err: err}
+	}
+	return nil
+}
+
+/*
+afterFind call AfterFind of root models and every bound relation (depth first, relation before parent),
+skip when no model type of the tree implement AfterFindHook
+*/
+func afterFind(ctx context.Context, slice reflect.Value) error {
+	if slice.Len() == 0 || !hasAfterFindHook(slice.Type().Elem(), make(map[reflect.Type]bool)) {
+		return nil
+	}
+	return walkModels(slice, func(elem reflect.Value, index int) error {
+		return callHook(ctx, hookAfterFind, elem, index)
+	})
+}
+
+/*
+walkModels call fn with every model of slice and its bound relation once,
+depth first and relation before parent. index is position in root slice or relation slice
+*/
+func walkModels(slice reflect.Value, fn func(elem reflect.Value, index int) error) error {
+	var visited = make(map[uintptr]bool)
+	var walk func(elem reflect.Value, index int) error
+	walk = func(elem reflect.Value, index int) error {
+		if elem.IsNil() || visited[elem.Pointer()] {
+			return nil
+		}
+		visited[elem.Pointer()] = true
+		meta, err := getModelMeta(elem.Type())
+		if err != nil {
+			return err
+		}
+		for _, name := range meta.fkFields {
+			relation := elem.Elem().FieldByIndex(meta.fieldByName[name].index)
+			switch relation.Kind() {
+			case reflect.Ptr:
+				if err := walk(relation, index); err != nil {
+					return err
+				}
+			case reflect.Slice:
+				for child := 0; child < relation.Len(); child++ {
+					if err := walk(relation.Index(child), child); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return fn(elem, index)
+	}
+	for index := 0; index < slice.Len(); index++ {
+		if err := walk(slice.Index(index), index); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hasAfterFindHook(modelType reflect.Type, visited map[reflect.Type]bool) bool {
+	if visited[modelType] {
+		return false
+	}
+	visited[modelType] = true
+	if modelType.Implements(afterFindHookType) {
+		return true
+	}
+	meta, err := getModelMeta(modelType)
+	if err != nil {
+		return false
+	}
+	for _, name := range meta.fkFields {
+		relationType := meta.fieldByName[name].fieldType
+		if relationType.Kind() == reflect.Slice {
+			relationType = relationType.Elem()
+		}
+		if hasAfterFindHook(relationType, visited) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package orm_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/Pheethy/psql/orm"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v2"
+)
+
+var errEmptyTitle = errors.New("title must not be empty")
+
+type Note struct {
+	TableName struct{}   `json:"-" db:"notes" pk:"ID"`
+	ID        int        `json:"id" db:"id"`
+	Title     string     `json:"title" db:"title"`
+	Comments  []*Comment `json:"comments" db:"-" fk:"fk_field1:ID,fk_field2:NoteID"`
+	calls     []string
+}
+
+func (n *Note) BeforeInsert(ctx context.Context) error {
+	if n.Title == "" {
+		return errEmptyTitle
+	}
+	n.calls = append(n.calls, "BeforeInsert")
+	return nil
+}
+
+func (n *Note) AfterInsert(ctx context.Context) error {
+	n.calls = append(n.calls, "AfterInsert")
+	return nil
+}
+
+func (n *Note) BeforeUpdate(ctx context.Context) error {
+	n.calls = append(n.calls, "BeforeUpdate")
+	return nil
+}
+
+func (n *Note) AfterUpdate(ctx context.Context) error {
+	n.calls = append(n.calls, "AfterUpdate")
+	return nil
+}
+
+func (n *Note) AfterDelete(ctx context.Context) error {
+	panic("note is deleted")
+}
+
+func (n *Note) AfterFind(ctx context.Context) error {
+	/* relation is bound and found before parent */
+	for _, comment := range n.Comments {
+		if !comment.found {
+			return errors.New("comment is not found before note")
+		}
+	}
+	n.calls = append(n.calls, "AfterFind")
+	return nil
+}
+
+type Comment struct {
+	TableName struct{} `json:"-" db:"comments" pk:"ID"`
+	ID        int      `json:"id" db:"id"`
+	NoteID    int      `json:"note_id" db:"note_id"`
+	found     bool
+}
+
+func (c *Comment) AfterFind(ctx context.Context) error {
+	c.found = true
+	return nil
+}
+
+func TestHook(t *testing.T) {
+	t.Run("success_insert_hook", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		note := &Note{Title: "Draft"}
+		dbmock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "notes" ("id","title") VALUES (DEFAULT,$1) RETURNING "id","title"`)).
+			WithArgs("Draft").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(1, "Draft"))
+
+		err := orm.Insert(context.Background(), db, note)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, note.ID)
+		assert.Equal(t, []string{"BeforeInsert", "AfterInsert"}, note.calls)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("success_upsert_after_insert_only_written", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		notes := []*Note{{ID: 1, Title: "Draft"}, {ID: 2, Title: "Final"}}
+		dbmock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "notes" ("id","title") VALUES ($1,$2),($3,$4) ON CONFLICT ("id") DO NOTHING`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(2, "Final"))
+
+		err := orm.UpsertBatch(context.Background(), db, notes, orm.NewUpsertOption().SetDoNothing())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"BeforeInsert"}, notes[0].calls)
+		assert.Equal(t, []string{"BeforeInsert", "AfterInsert"}, notes[1].calls)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("error_before_hook_abort_statement", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		notes := []*Note{{Title: "Draft"}, {}}
+
+		err := orm.InsertBatch(context.Background(), db, notes)
+		var hookErr *orm.HookError
+		assert.ErrorAs(t, err, &hookErr)
+		assert.ErrorIs(t, err, errEmptyTitle)
+		assert.Equal(t, "BeforeInsert", hookErr.Hook)
+		assert.Equal(t, "orm_test.Note", hookErr.Model)
+		assert.Equal(t, 1, hookErr.Index)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("success_update_hook", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		note := &Note{ID: 1, Title: "Final"}
+		dbmock.ExpectQuery(regexp.QuoteMeta(`UPDATE "notes"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(1, "Final"))
+
+		err := orm.Update(context.Background(), db, note)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"BeforeUpdate", "AfterUpdate"}, note.calls)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("success_update_changed_hook_only_updated", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		notes := []*Note{{ID: 1, Title: "Draft"}, {ID: 2, Title: "Final"}}
+		tracker := orm.NewTracker()
+		assert.NoError(t, tracker.Track(notes))
+		notes[1].Title = "Published"
+		dbmock.ExpectQuery(regexp.QuoteMeta(`UPDATE "notes" SET "title" = $1 WHERE "id" = $2`)).
+			WithArgs("Published", 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(2, "Published"))
+
+		err := orm.UpdateChangedBatch(context.Background(), db, tracker, notes)
+		assert.NoError(t, err)
+		assert.Empty(t, notes[0].calls)
+		assert.Equal(t, []string{"BeforeUpdate", "AfterUpdate"}, notes[1].calls)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("error_after_hook_panic", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		dbmock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "notes"`)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := orm.Delete(context.Background(), db, &Note{ID: 1})
+		var hookErr *orm.HookError
+		assert.ErrorAs(t, err, &hookErr)
+		assert.Equal(t, "AfterDelete", hookErr.Hook)
+		assert.Contains(t, err.Error(), "note is deleted")
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("success_after_find_relation_before_root", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		dbmock.ExpectQuery(`SELECT (.+) notes`).WillReturnRows(sqlmock.NewRows([]string{"notes.id", "notes.title", "comments.id", "comments.note_id"}).
+			AddRow(1, "Draft", 10, 1).
+			AddRow(1, "Draft", 11, 1).
+			AddRow(2, "Final", nil, nil),
+		)
+		rows, err := db.Queryx(`SELECT * FROM notes`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		mapper, err := orm.Orm(new(Note), rows, orm.NewMapperOption())
+		assert.NoError(t, err)
+		notes := mapper.GetData().([]*Note)
+		assert.Len(t, notes, 2)
+		for _, note := range notes {
+			assert.Equal(t, []string{"AfterFind"}, note.calls)
+		}
+		assert.Len(t, notes[0].Comments, 2)
+		assert.True(t, notes[0].Comments[0].found)
+		assert.True(t, notes[0].Comments[1].found)
+	})
+}
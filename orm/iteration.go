@@ -0,0 +1,179 @@
+package orm
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/emirpasic/gods/lists/arraylist"
+	"github.com/emirpasic/gods/maps/hashmap"
+	"github.com/spf13/cast"
+)
+
+/*
+iteration keep rows.Next() copy of SetIterationList / SetIterationHashMapWith...,
+value of column which is mapped into some model is decoded by registry of that field
+(uuid.UUID, time.Time, helper.Timestamp...), NULL and unmapped column keep the value from driver
+*/
+type iteration struct {
+	rows    []map[string]interface{}
+	rowByPK map[string]map[string]interface{}
+}
+
+func newIteration() *iteration {
+	return &iteration{
+		rows:    make([]map[string]interface{}, 0),
+		rowByPK: make(map[string]map[string]interface{}),
+	}
+}
+
+// GetIterationRows column -> value of every row from SetIterationList
+func (m Mapper) GetIterationRows() []map[string]interface{} {
+	return m.iteration.rows
+}
+
+// GetIterationRowsByKey pk (joined by fieldJoinKeyMap) -> column -> value from SetIterationHashMapWith...
+func (m Mapper) GetIterationRowsByKey() map[string]map[string]interface{} {
+	return m.iteration.rowByPK
+}
+
+/*
+GetIterationList values of every row from SetIterationList in column order.
+
+Deprecated: use GetIterationRows, will be removed in the next release
+*/
+func (m Mapper) GetIterationList() []*arraylist.List {
+	var lists = make([]*arraylist.List, 0, len(m.iteration.rows))
+	for _, data := range m.iteration.rows {
+		var values = make([]interface{}, 0, len(m.columns))
+		for _, col := range m.columns {
+			values = append(values, data[col.Name()])
+		}
+		lists = append(lists, arraylist.New(values...))
+	}
+	return lists
+}
+
+/*
+GetIterationHashMap pk -> *hashmap.Map of column -> value from SetIterationHashMapWith...
+
+Deprecated: use GetIterationRowsByKey, will be removed in the next release
+*/
+func (m Mapper) GetIterationHashMap() *hashmap.Map {
+	var rows = hashmap.New()
+	for key, data := range m.iteration.rowByPK {
+		var row = hashmap.New()
+		for column, val := range data {
+			row.Put(column, val)
+		}
+		rows.Put(key, row)
+	}
+	return rows
+}
+
+func addIteration(mapper *Mapper, options MapperOption, columns []*sql.ColumnType, columnM map[string]int, values []interface{}, row int) error {
+	if !options.copyIntoIteration {
+		return nil
+	}
+	switch options.iterTypes {
+	case ITERATION_TYPE_LIST:
+		decoded := decodeIterationValues(mapper.modelStructs, values, options)
+		var data = make(map[string]interface{}, len(columns))
+		for index, col := range columns {
+			if err := putIterationValue(data, col.Name(), decoded[index], row); err != nil {
+				return err
+			}
+		}
+		mapper.iteration.rows = append(mapper.iteration.rows, data)
+	case ITERATION_TYPE_HASH_MAP:
+		if len(options.pkIterMapKeys) == 0 {
+			return nil
+		}
+		var valKey = make([]string, 0, len(options.pkIterMapKeys))
+		for _, column := range options.pkIterMapKeys {
+			colIndex, found := columnM[column]
+			if !found {
+				return &MappingError{Column: column, Row: row, Err: ErrColumnNotFound}
+			}
+			val := values[colIndex]
+			if val == nil || val == "" {
+				/* skip row when some pk is null */
+				return nil
+			}
+			valKey = append(valKey, cast.ToString(val))
+		}
+
+		decoded := decodeIterationValues(mapper.modelStructs, values, options)
+		var data = make(map[string]interface{}, len(columns))
+		for index, col := range columns {
+			if len(options.mapStoreColumn) > 0 && !containsColumn(options.mapStoreColumn, col.Name()) {
+				continue
+			}
+			if err := putIterationValue(data, col.Name(), decoded[index], row); err != nil {
+				return err
+			}
+		}
+		mapper.iteration.rowByPK[strings.Join(valKey, fieldJoinKeyMap)] = data
+	}
+	return nil
+}
+
+// putIterationValue column name is key of row, same name twice is error instead of merge
+func putIterationValue(data map[string]interface{}, column string, val interface{}, row int) error {
+	if _, ok := data[column]; ok {
+		return &MappingError{Column: column, Row: row, Err: fmt.Errorf("%w: column name must be unique in iteration row", ErrDuplicateColumn)}
+	}
+	data[column] = val
+	return nil
+}
+
+/*
+decodeIterationValues take value of column from model of current row,
+first model (main, reference, sub reference) which map the column win
+*/
+func decodeIterationValues(ms []modelStruct, values []interface{}, options MapperOption) []interface{} {
+	var decoded = make([]interface{}, len(values))
+	var done = make([]bool, len(values))
+	for index, val := range values {
+		decoded[index] = val
+		done[index] = val == nil
+	}
+	var decode func(m *modelStruct)
+	decode = func(m *modelStruct) {
+		if m.current.IsValid() {
+			for index, field := range m.plan {
+				if field == nil || done[index] {
+					continue
+				}
+				if registry, err := field.getRegistry(options); err != nil || registry == nil {
+					continue
+				}
+				decoded[index] = field.value(m.current)
+				done[index] = true
+			}
+		}
+		for index := range m.subRefModel {
+			decode(&m.subRefModel[index])
+		}
+	}
+	for index := range ms {
+		if ms[index].IsMainModel() {
+			decode(&ms[index])
+		}
+	}
+	for index := range ms {
+		if !ms[index].IsMainModel() {
+			decode(&ms[index])
+		}
+	}
+	return decoded
+}
+
+func containsColumn(columns []string, column string) bool {
+	for _, val := range columns {
+		if val == column {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package orm_test
+
+import (
+	"testing"
+
+	"github.com/Pheethy/psql/orm"
+	"github.com/Pheethy/sqlx"
+	"github.com/emirpasic/gods/maps/hashmap"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v2"
+)
+
+func TestIteration(t *testing.T) {
+	var columns = []string{"orders.id", "orders.name", "toppings.id", "toppings.type", "toppings.order_id", "note"}
+	var newRows = func(t *testing.T, rows *sqlmock.Rows) *sqlx.Rows {
+		db, dbmock, err := sqlmock.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		dbmock.ExpectQuery(`SELECT (.+) orders`).WillReturnRows(rows)
+		sqlxRows, err := sqlx.NewDb(db, "sqlmock").Queryx(`SELECT * FROM orders`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { sqlxRows.Close() })
+		return sqlxRows
+	}
+	orderID, _ := uuid.NewV4()
+
+	t.Run("success_list_decoded_by_registry", func(t *testing.T) {
+		rows := newRows(t, sqlmock.NewRows(columns).
+			AddRow(orderID.String(), "Cake", 5001, "None", orderID.String(), "hot").
+			AddRow(orderID.String(), "Cake", nil, nil, nil, nil))
+
+		mapper, err := orm.Orm(new(Order), rows, orm.NewMapperOption().SetIterationList())
+		assert.NoError(t, err)
+
+		iterRows := mapper.GetIterationRows()
+		assert.Len(t, iterRows, 2)
+		assert.Equal(t, &orderID, iterRows[0]["orders.id"])
+		assert.Equal(t, "Cake", iterRows[0]["orders.name"])
+		assert.Equal(t, 5001, iterRows[0]["toppings.id"])
+		assert.Equal(t, &orderID, iterRows[0]["toppings.order_id"])
+		/* unmapped column keep driver value */
+		assert.Equal(t, "hot", iterRows[0]["note"])
+		assert.Nil(t, iterRows[1]["toppings.id"])
+		assert.Nil(t, iterRows[1]["note"])
+	})
+
+	t.Run("success_hash_map_only_store_columns", func(t *testing.T) {
+		rows := newRows(t, sqlmock.NewRows(columns).
+			AddRow(orderID.String(), "Cake", 5001, "None", orderID.String(), "hot").
+			AddRow(orderID.String(), "Cake", 5002, "Glazed", orderID.String(), "cold").
+			AddRow(orderID.String(), "Cake", nil, nil, nil, nil))
+
+		option := orm.NewMapperOption().SetIterationHashMapWithModel(new(Topping), []string{"toppings.type", "note"})
+		mapper, err := orm.Orm(new(Order), rows, option)
+		assert.NoError(t, err)
+
+		rowsByKey := mapper.GetIterationRowsByKey()
+		assert.Len(t, rowsByKey, 2)
+		assert.Equal(t, map[string]interface{}{"toppings.type": "None", "note": "hot"}, rowsByKey["5001"])
+		assert.Equal(t, map[string]interface{}{"toppings.type": "Glazed", "note": "cold"}, rowsByKey["5002"])
+	})
+
+	t.Run("success_deprecated_gods_getters", func(t *testing.T) {
+		rows := newRows(t, sqlmock.NewRows(columns).
+			AddRow(orderID.String(), "Cake", 5001, "None", orderID.String(), "hot"))
+		mapper, err := orm.Orm(new(Order), rows, orm.NewMapperOption().SetIterationList())
+		assert.NoError(t, err)
+		lists := mapper.GetIterationList()
+		if assert.Len(t, lists, 1) {
+			assert.Equal(t, []interface{}{&orderID, "Cake", 5001, "None", &orderID, "hot"}, lists[0].Values())
+		}
+
+		rows = newRows(t, sqlmock.NewRows(columns).
+			AddRow(orderID.String(), "Cake", 5001, "None", orderID.String(), "hot"))
+		option := orm.NewMapperOption().SetIterationHashMapWithModel(new(Topping), []string{"toppings.type"})
+		mapper, err = orm.Orm(new(Order), rows, option)
+		assert.NoError(t, err)
+		row, ok := mapper.GetIterationHashMap().Get("5001")
+		if assert.True(t, ok) {
+			val, _ := row.(*hashmap.Map).Get("toppings.type")
+			assert.Equal(t, "None", val)
+		}
+	})
+
+	t.Run("error_duplicate_column_name", func(t *testing.T) {
+		rows := newRows(t, sqlmock.NewRows([]string{"orders.id", "orders.name", "note", "note"}).
+			AddRow(orderID.String(), "Cake", "hot", "cold"))
+
+		_, err := orm.Orm(new(Order), rows, orm.NewMapperOption().SetIterationList())
+		assert.ErrorIs(t, err, orm.ErrDuplicateColumn)
+
+		rows = newRows(t, sqlmock.NewRows([]string{"orders.id", "orders.name", "note", "note"}).
+			AddRow(orderID.String(), "Cake", "hot", "cold"))
+		_, err = orm.Orm(new(Order), rows, orm.NewMapperOption().SetIterationHashMapWithModel(new(Order), []string{"note"}))
+		assert.ErrorIs(t, err, orm.ErrDuplicateColumn)
+	})
+
+	t.Run("error_hash_map_column_not_found", func(t *testing.T) {
+		rows := newRows(t, sqlmock.NewRows(columns).AddRow(orderID.String(), "Cake", 5001, "None", orderID.String(), "hot"))
+
+		_, err := orm.Orm(new(Order), rows, orm.NewMapperOption().SetIterationHashMapWithColumns([]string{"unknown"}, nil))
+		assert.ErrorIs(t, err, orm.ErrColumnNotFound)
+	})
+}
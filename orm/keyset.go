@@ -0,0 +1,337 @@
+package orm
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Pheethy/psql/helper"
+)
+
+const (
+	cursorNext = "next"
+	cursorPrev = "prev"
+)
+
+/*
+Keyset is cursor pagination of model by sort key (orderBy + pk),
+cursor is last seen sort key encoded by registry of field and signed with secret (HMAC-SHA256).
+sort key column must be not null
+*/
+type Keyset struct {
+	meta    *modelMeta
+	fields  []*fieldMeta
+	orders  []orderField
+	columns []string // "table"."column" of fields
+	secret  []byte
+	options MapperOption // registry of sort key value
+}
+
+type cursorToken struct {
+	Direction string        `json:"d"`
+	Keys      []string      `json:"k"`
+	Values    []cursorValue `json:"v"`
+}
+
+type cursorValue struct {
+	Type  string `json:"t"`
+	Value string `json:"v"`
+}
+
+/*
+NewKeyset orderBy is field name with optional direction, example NewKeyset(new(Order), secret, "CreatedAt desc"),
+pk is appended with direction of the last order when it is not in orderBy
+*/
+func NewKeyset(model interface{}, secret []byte, orderBy ...string) (Keyset, error) {
+	return NewKeysetWithOption(NewMapperOption(), model, secret, orderBy...)
+}
+
+// NewKeysetWithOption same as NewKeyset with pk of SetOverridePKField and registry of MapperOption
+func NewKeysetWithOption(options MapperOption, model interface{}, secret []byte, orderBy ...string) (Keyset, error) {
+	if len(secret) == 0 {
+		return Keyset{}, fmt.Errorf("%w: secret must not be empty", ErrInvalidCursor)
+	}
+	if options.registryErr != nil {
+		return Keyset{}, options.registryErr
+	}
+	meta, err := getModelMeta(model)
+	if err != nil {
+		return Keyset{}, err
+	}
+	orders, err := parseOrderTag(strings.Join(orderBy, fieldSeperate))
+	if err != nil {
+		return Keyset{}, err
+	}
+	for _, pkField := range meta.getPKFields(options) {
+		var exists, desc bool
+		for _, order := range orders {
+			exists = exists || order.name == pkField
+			desc = order.desc
+		}
+		if !exists {
+			orders = append(orders, orderField{name: pkField, desc: desc})
+		}
+	}
+
+	var names = make([]string, 0, len(orders))
+	for _, order := range orders {
+		names = append(names, order.name)
+	}
+	fields, err := meta.getFields(names)
+	if err != nil {
+		return Keyset{}, err
+	}
+	var columns = make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field.column == "" {
+			return Keyset{}, &MappingError{Model: meta.modelType.String(), Field: field.name, Row: -1, Err: ErrColumnNotFound}
+		}
+		columns = append(columns, quoteIdentifier(meta.table)+"."+quoteIdentifier(field.column))
+	}
+
+	return Keyset{meta: meta, fields: fields, orders: orders, columns: columns, secret: secret, options: options}, nil
+}
+
+/*
+Condition of cursor without WHERE keyword, placeholder start after offset args of query,
+example `("orders"."created_at","orders"."id") < ($1,$2)`. empty cursor is first page, condition is ""
+*/
+func (k Keyset) Condition(cursor string, offset int) (string, []interface{}, error) {
+	if cursor == "" {
+		return "", nil, nil
+	}
+	token, err := k.decode(cursor)
+	if err != nil {
+		return "", nil, err
+	}
+	var args = make([]interface{}, 0, len(token.Values))
+	var placeholders = make([]string, 0, len(token.Values))
+	for _, val := range token.Values {
+		arg, err := val.decode()
+		if err != nil {
+			return "", nil, err
+		}
+		args = append(args, arg)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", offset+len(args)))
+	}
+
+	var operator = func(order orderField) string {
+		if order.desc != (token.Direction == cursorPrev) {
+			return "<"
+		}
+		return ">"
+	}
+	var sameDirection = true
+	for _, order := range k.orders {
+		sameDirection = sameDirection && order.desc == k.orders[0].desc
+	}
+	if sameDirection {
+		return fmt.Sprintf("(%s) %s (%s)", strings.Join(k.columns, ","), operator(k.orders[0]), strings.Join(placeholders, ",")), args, nil
+	}
+
+	/* mixed direction, (a < $1) OR (a = $1 AND b > $2) */
+	var conditions = make([]string, 0, len(k.columns))
+	for index := range k.columns {
+		var terms = make([]string, 0, index+1)
+		for prev := 0; prev < index; prev++ {
+			terms = append(terms, fmt.Sprintf("%s = %s", k.columns[prev], placeholders[prev]))
+		}
+		terms = append(terms, fmt.Sprintf("%s %s %s", k.columns[index], operator(k.orders[index]), placeholders[index]))
+		conditions = append(conditions, "("+strings.Join(terms, " AND ")+")")
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args, nil
+}
+
+// OrderBy of cursor without ORDER BY keyword, prev cursor is reversed order
+func (k Keyset) OrderBy(cursor string) (string, error) {
+	var backward bool
+	if cursor != "" {
+		token, err := k.decode(cursor)
+		if err != nil {
+			return "", err
+		}
+		backward = token.Direction == cursorPrev
+	}
+	var orders = make([]string, 0, len(k.columns))
+	for index, order := range k.orders {
+		if order.desc != backward {
+			orders = append(orders, k.columns[index]+" DESC")
+		} else {
+			orders = append(orders, k.columns[index]+" ASC")
+		}
+	}
+	return strings.Join(orders, ", "), nil
+}
+
+/*
+Paginate models ([]*Model of query with LIMIT paginator.Limit()) into page,
+extra row is trimmed, prev page is reversed back into sort order and next/prev cursor is set into paginator
+*/
+func (k Keyset) Paginate(paginator *helper.CursorPaginator, models interface{}) (interface{}, error) {
+	if paginator.PerPage < 1 {
+		return models, ErrInvalidPaginate
+	}
+	slice := reflect.ValueOf(models)
+	if slice.Kind() != reflect.Slice || slice.Type().Elem() != reflect.PtrTo(k.meta.modelType) {
+		return models, ErrMustBeSlice
+	}
+	var direction string
+	if paginator.Cursor != "" {
+		token, err := k.decode(paginator.Cursor)
+		if err != nil {
+			return models, err
+		}
+		direction = token.Direction
+	}
+
+	hasMore := slice.Len() > paginator.PerPage
+	if hasMore {
+		slice = slice.Slice(0, paginator.PerPage)
+	}
+	var page = reflect.MakeSlice(slice.Type(), 0, slice.Len())
+	for index := 0; index < slice.Len(); index++ {
+		if direction == cursorPrev {
+			page = reflect.Append(page, slice.Index(slice.Len()-1-index))
+		} else {
+			page = reflect.Append(page, slice.Index(index))
+		}
+	}
+
+	switch direction {
+	case cursorPrev:
+		paginator.HasNext, paginator.HasPrev = true, hasMore
+	case cursorNext:
+		paginator.HasNext, paginator.HasPrev = hasMore, true
+	default:
+		paginator.HasNext, paginator.HasPrev = hasMore, false
+	}
+	paginator.NextCursor, paginator.PrevCursor = "", ""
+	if page.Len() == 0 {
+		return page.Interface(), nil
+	}
+	if paginator.HasNext {
+		cursor, err := k.encode(cursorNext, page.Index(page.Len()-1))
+		if err != nil {
+			return page.Interface(), err
+		}
+		paginator.NextCursor = cursor
+	}
+	if paginator.HasPrev {
+		cursor, err := k.encode(cursorPrev, page.Index(0))
+		if err != nil {
+			return page.Interface(), err
+		}
+		paginator.PrevCursor = cursor
+	}
+	return page.Interface(), nil
+}
+
+func (k Keyset) encode(direction string, elem reflect.Value) (string, error) {
+	token := cursorToken{Direction: direction, Keys: k.columns, Values: make([]cursorValue, 0, len(k.fields))}
+	for _, field := range k.fields {
+		val, err := getColumnValue(elem, writeColumn{name: field.column, field: field.name, meta: field}, k.options)
+		if err != nil {
+			return "", err
+		}
+		cursorVal, err := newCursorValue(val)
+		if err != nil {
+			return "", &MappingError{Model: k.meta.modelType.String(), Field: field.name, Row: -1, Value: val, Err: err}
+		}
+		token.Values = append(token.Values, cursorVal)
+	}
+	payload, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(k.sign(payload)), nil
+}
+
+func (k Keyset) decode(cursor string) (cursorToken, error) {
+	var token cursorToken
+	parts := strings.Split(cursor, ".")
+	if len(parts) != 2 {
+		return token, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return token, ErrInvalidCursor
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, k.sign(payload)) {
+		return token, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(payload, &token); err != nil {
+		return token, ErrInvalidCursor
+	}
+	if (token.Direction != cursorNext && token.Direction != cursorPrev) ||
+		len(token.Values) != len(k.columns) || strings.Join(token.Keys, fieldSeperate) != strings.Join(k.columns, fieldSeperate) {
+		/* cursor of other keyset */
+		return token, ErrInvalidCursor
+	}
+	return token, nil
+}
+
+func (k Keyset) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, k.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// newCursorValue keep type of driver value, json number can not hold int64 and time
+func newCursorValue(val interface{}) (cursorValue, error) {
+	switch v := val.(type) {
+	case nil:
+		return cursorValue{}, fmt.Errorf("%w: sort key must not be null", ErrInvalidCursor)
+	case time.Time:
+		return cursorValue{Type: "time", Value: v.Format(time.RFC3339Nano)}, nil
+	case []byte:
+		return cursorValue{Type: "bytes", Value: base64.StdEncoding.EncodeToString(v)}, nil
+	}
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cursorValue{Type: "int", Value: strconv.FormatInt(rv.Int(), 10)}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cursorValue{Type: "uint", Value: strconv.FormatUint(rv.Uint(), 10)}, nil
+	case reflect.Float32, reflect.Float64:
+		return cursorValue{Type: "float", Value: strconv.FormatFloat(rv.Float(), 'g', -1, 64)}, nil
+	case reflect.Bool:
+		return cursorValue{Type: "bool", Value: strconv.FormatBool(rv.Bool())}, nil
+	case reflect.String:
+		return cursorValue{Type: "string", Value: rv.String()}, nil
+	}
+	return cursorValue{}, fmt.Errorf("%w: unsupported sort key type %T", ErrInvalidCursor, val)
+}
+
+func (c cursorValue) decode() (interface{}, error) {
+	var val interface{}
+	var err error
+	switch c.Type {
+	case "time":
+		val, err = time.Parse(time.RFC3339Nano, c.Value)
+	case "bytes":
+		val, err = base64.StdEncoding.DecodeString(c.Value)
+	case "int":
+		val, err = strconv.ParseInt(c.Value, 10, 64)
+	case "uint":
+		val, err = strconv.ParseUint(c.Value, 10, 64)
+	case "float":
+		val, err = strconv.ParseFloat(c.Value, 64)
+	case "bool":
+		val, err = strconv.ParseBool(c.Value)
+	case "string":
+		val = c.Value
+	default:
+		return nil, ErrInvalidCursor
+	}
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return val, nil
+}
@@ -0,0 +1,124 @@
+package orm_test
+
+import (
+	"testing"
+
+	"github.com/Pheethy/psql/helper"
+	"github.com/Pheethy/psql/orm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyset(t *testing.T) {
+	var secret = []byte("keyset-secret")
+	keyset, err := orm.NewKeyset(new(Menu), secret, "Name desc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("success_first_page_and_next_cursor", func(t *testing.T) {
+		condition, args, err := keyset.Condition("", 0)
+		assert.NoError(t, err)
+		assert.Empty(t, condition)
+		assert.Empty(t, args)
+		orderBy, err := keyset.OrderBy("")
+		assert.NoError(t, err)
+		assert.Equal(t, `"menus"."name" DESC, "menus"."id" DESC`, orderBy)
+
+		paginator := helper.NewCursorPaginator("", 2)
+		assert.Equal(t, 3, paginator.Limit())
+		page, err := keyset.Paginate(&paginator, []*Menu{{ID: 3, Name: "C"}, {ID: 2, Name: "B"}, {ID: 1, Name: "A"}})
+		assert.NoError(t, err)
+		assert.Len(t, page.([]*Menu), 2)
+		assert.True(t, paginator.HasNext)
+		assert.False(t, paginator.HasPrev)
+		assert.Empty(t, paginator.PrevCursor)
+
+		condition, args, err = keyset.Condition(paginator.NextCursor, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, `("menus"."name","menus"."id") < ($2,$3)`, condition)
+		assert.Equal(t, []interface{}{"B", int64(2)}, args)
+	})
+
+	t.Run("success_prev_cursor_reverse_order", func(t *testing.T) {
+		paginator := helper.NewCursorPaginator("", 2)
+		_, err := keyset.Paginate(&paginator, []*Menu{{ID: 3, Name: "C"}, {ID: 2, Name: "B"}, {ID: 1, Name: "A"}})
+		assert.NoError(t, err)
+
+		/* second page */
+		paginator = helper.NewCursorPaginator(paginator.NextCursor, 2)
+		page, err := keyset.Paginate(&paginator, []*Menu{{ID: 1, Name: "A"}})
+		assert.NoError(t, err)
+		assert.Len(t, page.([]*Menu), 1)
+		assert.False(t, paginator.HasNext)
+		assert.True(t, paginator.HasPrev)
+
+		/* back to first page, query in reversed order */
+		prevCursor := paginator.PrevCursor
+		condition, args, err := keyset.Condition(prevCursor, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, `("menus"."name","menus"."id") > ($1,$2)`, condition)
+		assert.Equal(t, []interface{}{"A", int64(1)}, args)
+		orderBy, err := keyset.OrderBy(prevCursor)
+		assert.NoError(t, err)
+		assert.Equal(t, `"menus"."name" ASC, "menus"."id" ASC`, orderBy)
+
+		paginator = helper.NewCursorPaginator(prevCursor, 2)
+		page, err = keyset.Paginate(&paginator, []*Menu{{ID: 2, Name: "B"}, {ID: 3, Name: "C"}})
+		assert.NoError(t, err)
+		menus := page.([]*Menu)
+		assert.Equal(t, []int{3, 2}, []int{menus[0].ID, menus[1].ID})
+		assert.True(t, paginator.HasNext)
+		assert.False(t, paginator.HasPrev)
+	})
+
+	t.Run("success_mixed_direction", func(t *testing.T) {
+		mixed, err := orm.NewKeyset(new(Menu), secret, "Name", "ID desc")
+		assert.NoError(t, err)
+		paginator := helper.NewCursorPaginator("", 1)
+		_, err = mixed.Paginate(&paginator, []*Menu{{ID: 2, Name: "A"}, {ID: 1, Name: "A"}})
+		assert.NoError(t, err)
+
+		condition, _, err := mixed.Condition(paginator.NextCursor, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, `(("menus"."name" > $1) OR ("menus"."name" = $1 AND "menus"."id" < $2))`, condition)
+	})
+
+	t.Run("success_override_pk_with_option", func(t *testing.T) {
+		options := orm.NewMapperOption().SetOverridePKField(orm.NewMapperOptionPKField(Menu{}, []string{"Name"}))
+		byName, err := orm.NewKeysetWithOption(options, new(Menu), secret)
+		assert.NoError(t, err)
+		orderBy, err := byName.OrderBy("")
+		assert.NoError(t, err)
+		assert.Equal(t, `"menus"."name" ASC`, orderBy)
+
+		paginator := helper.NewCursorPaginator("", 1)
+		_, err = byName.Paginate(&paginator, []*Menu{{ID: 2, Name: "A"}, {ID: 1, Name: "B"}})
+		assert.NoError(t, err)
+		condition, args, err := byName.Condition(paginator.NextCursor, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, `("menus"."name") > ($1)`, condition)
+		assert.Equal(t, []interface{}{"A"}, args)
+	})
+
+	t.Run("error_tampered_or_foreign_cursor", func(t *testing.T) {
+		paginator := helper.NewCursorPaginator("", 1)
+		_, err := keyset.Paginate(&paginator, []*Menu{{ID: 2, Name: "B"}, {ID: 1, Name: "A"}})
+		assert.NoError(t, err)
+
+		_, _, err = keyset.Condition("x"+paginator.NextCursor, 0)
+		assert.ErrorIs(t, err, orm.ErrInvalidCursor)
+
+		other, err := orm.NewKeyset(new(Menu), []byte("other-secret"), "Name desc")
+		assert.NoError(t, err)
+		_, _, err = other.Condition(paginator.NextCursor, 0)
+		assert.ErrorIs(t, err, orm.ErrInvalidCursor)
+
+		byID, err := orm.NewKeyset(new(Menu), secret)
+		assert.NoError(t, err)
+		_, _, err = byID.Condition(paginator.NextCursor, 0)
+		assert.ErrorIs(t, err, orm.ErrInvalidCursor)
+
+		_, err = orm.NewKeyset(new(Menu), nil)
+		assert.ErrorIs(t, err, orm.ErrInvalidCursor)
+	})
+}
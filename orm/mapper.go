@@ -8,36 +8,47 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/Pheethy/psql/helper"
 	"github.com/Pheethy/sqlx"
-	"github.com/emirpasic/gods/lists/arraylist"
-	"github.com/emirpasic/gods/maps/hashmap"
 	"github.com/fatih/structs"
-	"github.com/spf13/cast"
 	"golang.org/x/sync/errgroup"
 )
 
 type Mapper struct {
-	modelStructs    []modelStruct
-	rowCount        int
-	paginateTotal   int
-	columns         []*sql.ColumnType
-	arrayListValues []*arraylist.List
-	hashMapValues   *hashmap.Map
-	options         MapperOption
+	modelStructs  []modelStruct
+	rowCount      int
+	paginateTotal int
+	columns       []*sql.ColumnType
+	iteration     *iteration
+	tracker       *Tracker
+	options       MapperOption
+	warnings      []*MappingError
 }
 
 func newMapper(mainModel interface{}, options MapperOption) (Mapper, error) {
 	mapper := Mapper{
-		modelStructs:    make([]modelStruct, 0),
-		columns:         make([]*sql.ColumnType, 0),
-		arrayListValues: make([]*arraylist.List, 0),
-		hashMapValues:   hashmap.New(),
-		options:         options,
+		modelStructs: make([]modelStruct, 0),
+		columns:      make([]*sql.ColumnType, 0),
+		iteration:    newIteration(),
+		options:      options,
+	}
+	if options.registryErr != nil {
+		return mapper, options.registryErr
 	}
 	ms, err := newModelStruct(mainModel, options)
 	if err != nil {
 		return mapper, err
 	}
+	if len(options.preloads) > 0 {
+		/* preload relation is not join into root rows */
+		var joined = make([]modelStruct, 0, len(ms))
+		for index := range ms {
+			if ms[index].IsMainModel() || !options.isPreload(ms[index].fieldname) {
+				joined = append(joined, ms[index])
+			}
+		}
+		ms = joined
+	}
 	if len(ms) > 0 && options.autobinding {
 		for index := range ms {
 			if ms[index].IsMainModel() {
@@ -50,12 +61,18 @@ func newMapper(mainModel interface{}, options MapperOption) (Mapper, error) {
 			if len(subModels) > 1 {
 				for subIndex := range subModels {
 					subModels[subIndex].isReferenceModel = false
+					if err := subModels[subIndex].setKeyFields(); err != nil {
+						return mapper, err
+					}
 				}
 				ms[index].subRefModel = subModels
 			}
 		}
 	}
 
+	for index := range ms {
+		ms[index].isRoot = ms[index].IsMainModel()
+	}
 	mapper.modelStructs = ms
 	return mapper, nil
 }
@@ -64,10 +81,16 @@ func (m Mapper) GetData() interface{} {
 	return modelStructs(m.modelStructs).GetMainModel().modelSlice.Interface()
 }
 
+// GetRowCount count of rows from query, joined rows of the same root are counted
 func (m Mapper) GetRowCount() int {
 	return m.rowCount
 }
 
+// GetRootCount count of distinct root model
+func (m Mapper) GetRootCount() int {
+	return modelStructs(m.modelStructs).GetMainModel().modelSlice.Len()
+}
+
 func (m Mapper) GetPaginateTotal() int {
 	return m.paginateTotal
 }
@@ -76,14 +99,14 @@ func (m Mapper) GetColumns() []*sql.ColumnType {
 	return m.columns
 }
 
-// iter -> []interface{}
-func (m Mapper) GetIterationList() []*arraylist.List {
-	return m.arrayListValues
+// GetTracker snapshot of mapped models from MapperOption.SetTrackChanges(), nil when not set
+func (m Mapper) GetTracker() *Tracker {
+	return m.tracker
 }
 
-// get(id) -> *hashmap.Map
-func (m Mapper) GetIterationHashMap() *hashmap.Map {
-	return m.hashMapValues
+// strict mode warning from MapperOption.SetStrictWarning() and ErrSoftDeletedRoot of paginate query
+func (m Mapper) GetWarnings() []*MappingError {
+	return m.warnings
 }
 
 func validateModel(model interface{}) error {
@@ -98,17 +121,22 @@ func validateModel(model interface{}) error {
 	return nil
 }
 
-func recovery() error {
-	if r := recover(); r != nil {
-		if v, ok := r.(error); ok {
-			return v
-		} else if reflect.TypeOf(v).Kind() == reflect.String {
-			return errors.New(cast.ToString(v))
-		}
+// recovery convert value from recover() into error, must call recover() in deferred function itself
+func recovery(r interface{}) error {
+	if r == nil {
+		return nil
 	}
-	return nil
+	if v, ok := r.(error); ok {
+		return fmt.Errorf("panic: %w", v)
+	}
+	return fmt.Errorf("panic: %v", r)
 }
 
+/*
+orm map rows into model, root and child slice keep first seen row order
+(rows is read one by one, goroutine only split model of the same row).
+child slice can be sorted with `order:"Field,Field2 desc"` on relation field
+*/
 func orm(ctx context.Context, model interface{}, rows *sqlx.Rows, options MapperOption) (Mapper, error) {
 	if err := validateModel(model); err != nil {
 		return Mapper{}, err
@@ -123,12 +151,27 @@ func orm(ctx context.Context, model interface{}, rows *sqlx.Rows, options Mapper
 		return mapper, err
 	}
 	mapper.columns = columns
-	columnNameM := hashmap.New()
+	var nulls = new(nullFields)
+	for index := range mapper.modelStructs {
+		mapper.modelStructs[index].setPlan(columns, nulls)
+	}
+	if options.strictMode != "" {
+		issues := checkStrict(mapper.modelStructs, columns, options)
+		if len(issues) > 0 && options.strictMode == STRICT_MODE_ERROR {
+			var errs = make([]error, 0, len(issues))
+			for _, issue := range issues {
+				errs = append(errs, issue)
+			}
+			return mapper, errors.Join(errs...)
+		}
+		mapper.warnings = issues
+	}
+	columnNameM := make(map[string]int, len(columns))
 
 	var paginateColumnIndex = -1
 	if len(columns) > 0 {
 		for index, col := range columns {
-			columnNameM.Put(col.Name(), index)
+			columnNameM[col.Name()] = index
 			if strings.EqualFold(col.Name(), PAGINATE_COLUMN_NAME) {
 				paginateColumnIndex = index
 			}
@@ -146,20 +189,23 @@ func orm(ctx context.Context, model interface{}, rows *sqlx.Rows, options Mapper
 			return mapper, err
 		}
 		rowCount++
+		row := rowCount - 1
 		if paginateColumnIndex != -1 {
-			paginateTotal = cast.ToInt(values[paginateColumnIndex])
+			if paginateTotal, err = helper.ParseTotal(values[paginateColumnIndex]); err != nil {
+				return mapper, &MappingError{Column: PAGINATE_COLUMN_NAME, Row: row, Value: values[paginateColumnIndex], Err: err}
+			}
 		}
 
 		var group, _ = errgroup.WithContext(ctx)
 		var fillData = func(ms *modelStruct) {
-			group.Go(func() error {
+			group.Go(func() (err error) {
 				defer func() {
-					if panicErr := recovery(); panicErr != nil {
+					if panicErr := recovery(recover()); panicErr != nil {
 						err = panicErr
 					}
 				}()
 
-				slice, err := fillValueList(ms, columns, values, options)
+				slice, err := fillValueList(ms, columns, values, row, options)
 				if err != nil {
 					return err
 				}
@@ -191,23 +237,32 @@ func orm(ctx context.Context, model interface{}, rows *sqlx.Rows, options Mapper
 			return mapper, err
 		}
 
-		if err := addIteration(&mapper, options, columns, columnNameM, values); err != nil {
+		if err := addIteration(&mapper, options, columns, columnNameM, values, row); err != nil {
 			return mapper, err
 		}
 	}
 
+	/* root filtered in memory is still counted in total_row, query must use SoftDeleteCondition */
+	if main := modelStructs(mapper.modelStructs).GetMainModel(); paginateColumnIndex != -1 && main.hasDeleted {
+		issue := &MappingError{Model: main.meta.modelType.String(), Column: PAGINATE_COLUMN_NAME, Row: -1, Err: ErrSoftDeletedRoot}
+		if options.strictMode == STRICT_MODE_ERROR {
+			return mapper, issue
+		}
+		mapper.warnings = append(mapper.warnings, issue)
+	}
+
 	/* orm relation with pkMainModel Id */
 	if len(mapper.modelStructs) > 1 && options.autobinding {
 		mainModel := modelStructs(mapper.modelStructs).GetMainModel()
 		var bind = func(ctx context.Context, group *errgroup.Group, elem reflect.Value, refFields []string, allmodels []modelStruct) {
-			group.Go(func() error {
+			group.Go(func() (err error) {
 				defer func() {
-					if panicErr := recovery(); panicErr != nil {
+					if panicErr := recovery(recover()); panicErr != nil {
 						err = panicErr
 					}
 				}()
 
-				return bindReference(ctx, elem, refFields, allmodels)
+				return bindReference(ctx, elem, refFields, allmodels, options)
 			})
 		}
 		/* orm sub component */
@@ -253,6 +308,25 @@ func orm(ctx context.Context, model interface{}, rows *sqlx.Rows, options Mapper
 		}
 	}
 
+	if len(options.preloads) > 0 {
+		if err := preload(ctx, &mapper, options); err != nil {
+			return mapper, err
+		}
+	}
+	if options.trackChanges {
+		/* snapshot is loaded value, before AfterFind */
+		mapper.tracker = NewTracker()
+		mapper.tracker.options = options
+		if err := mapper.tracker.trackModels(modelStructs(mapper.modelStructs).GetMainModel().modelSlice); err != nil {
+			return mapper, err
+		}
+	}
+	if !options.skipAfterFind {
+		if err := afterFind(ctx, modelStructs(mapper.modelStructs).GetMainModel().modelSlice); err != nil {
+			return mapper, err
+		}
+	}
+
 	mapper.rowCount = rowCount
 	mapper.paginateTotal = paginateTotal
 	return mapper, nil
@@ -266,86 +340,93 @@ func Orm(model interface{}, rows *sqlx.Rows, options MapperOption) (Mapper, erro
 	return orm(context.Background(), model, rows, options)
 }
 
-func GetSelector(models interface{}) string {
-	faith := structs.New(models)
-	fields := faith.Fields()
-	tablename := getTableName(faith)
+/*
+GetSelector return `tablename.column "tablename.column"` of every column,
+with alias (alias of fk tag) return `alias.column "alias.column"` for model which is joined as alias
+*/
+func GetSelector(models interface{}, alias ...string) string {
+	meta, err := getModelMeta(models)
+	if err != nil {
+		return ""
+	}
+	tablename := meta.table
+	if len(alias) > 0 && alias[0] != "" {
+		tablename = alias[0]
+	}
 	var selectors = make([]string, 0)
 	var patternSelector = func(tablename string, fieldDB string) string {
 		return fmt.Sprintf(`%s.%s "%s.%s"`, tablename, fieldDB, tablename, fieldDB)
 	}
 
-	if len(fields) > 0 {
-		for _, field := range fields {
-			if field.Name() != TABLE_FIELD_NAME {
-				columename := field.Tag(TAGNAME)
-				if columename != "" && columename != "-" {
-					selectors = append(selectors, patternSelector(tablename, columename))
-				}
-			}
+	/* embedded struct is flattened */
+	for _, field := range meta.fields {
+		if field.column != "" {
+			selectors = append(selectors, patternSelector(tablename, field.column))
 		}
 	}
 
 	return strings.Join(selectors, ",")
 }
 
-func fillValueList(ms *modelStruct, columns []*sql.ColumnType, values []interface{}, options MapperOption) (reflect.Value, error) {
+func fillValueList(ms *modelStruct, columns []*sql.ColumnType, values []interface{}, row int, options MapperOption) (reflect.Value, error) {
 	slice := ms.modelSlice
 	model := ms.model
-	ptr := copy(reflect.ValueOf(model)).Interface()
-	if err := fillValue(ptr, columns, values); err != nil {
+	reflectValPtr := copy(reflect.ValueOf(model))
+	if err := fillByPlan(reflectValPtr.Interface(), ms.plan, columns, values, row, options); err != nil {
 		return slice, err
 	}
-	reflectValPtr := reflect.ValueOf(ptr)
-	exists, err := isDuplicateByPK(ms, slice, reflectValPtr, options)
+	ms.current = reflectValPtr
+	if !ms.isVisible(reflectValPtr, options) {
+		ms.hasDeleted = ms.hasDeleted || ms.isRoot
+		return slice, nil
+	}
+	exists, err := isDuplicateByPK(ms, slice, reflectValPtr, values, row, options)
 	if err != nil {
 		return slice, err
 	}
 	if !exists {
 		slice = reflect.Append(slice, reflectValPtr)
+		ms.nulls.store(reflectValPtr, ms.plan, values)
 	}
 	return slice, nil
 }
 
-func isDuplicateByPK(ms *modelStruct, slice reflect.Value, ptr reflect.Value, options MapperOption) (bool, error) {
-	pkM := ms.pkM
-	faith := structs.New(ptr.Interface())
-	var storePK = func(elemFaith *structs.Struct, fields []string) (bool, error) {
-		pkId, err := getIds(elemFaith, fields)
-		if err != nil {
-			return false, err
-		}
-		if pkId == "" || pkId == "0" || pkId == "false" {
-			return true, nil
-		}
-		if _, ok := pkM.Load(pkId); ok {
+/*
+isDuplicateByPK return true when key is already seen or absent,
+key is absent when model is not selected or some key column is NULL
+*/
+func isDuplicateByPK(ms *modelStruct, slice reflect.Value, ptr reflect.Value, values []interface{}, row int, options MapperOption) (bool, error) {
+	if !ms.isSelected {
+		return true, nil
+	}
+	for _, columnIndex := range ms.keyColumns {
+		if columnIndex != -1 && values[columnIndex] == nil {
 			return true, nil
 		}
-		pkM.Store(pkId, slice.Len()-1)
-
-		return false, nil
-	}
-
-	var allFields = []string{}
-	var elemFaith *structs.Struct
-	switch ms.IsMainModel() {
-	case true:
-		/* for main model */
-		allFields, _ = getFieldMetaData(faith, options)
-		elemFaith = structs.New(ptr.Interface())
-	default:
-		/* for reference model */
-		allFields, _ = getFieldMetaData(faith, options)
-		elemFaith = structs.New(ptr.Interface())
-		if len(ms.refFields) > 0 {
-			allFields = append(allFields, ms.refFields...)
+	}
+	pkId, err := getIds(ptr, ms.keyFields, options)
+	if err != nil {
+		if mappingErr, ok := err.(*MappingError); ok {
+			mappingErr.Row = row
 		}
+		return false, err
+	}
+	if pkId == "" {
+		return true, nil
+	}
+	if _, ok := ms.pkM.Load(pkId); ok {
+		return true, nil
 	}
+	ms.pkM.Store(pkId, slice.Len()-1)
 
-	return storePK(elemFaith, allFields)
+	return false, nil
 }
 
-func bindReference(ctx context.Context, mainElem reflect.Value, mainRefFieldNames []string, allModels []modelStruct) error {
+func bindReference(ctx context.Context, mainElem reflect.Value, mainRefFieldNames []string, allModels []modelStruct, options MapperOption) error {
+	meta, err := getModelMeta(mainElem.Type())
+	if err != nil {
+		return err
+	}
 	faith := structs.New(mainElem.Interface())
 	if len(mainRefFieldNames) > 0 {
 		var group, _ = errgroup.WithContext(ctx)
@@ -353,8 +434,8 @@ func bindReference(ctx context.Context, mainElem reflect.Value, mainRefFieldName
 			func(mainElem reflect.Value, refField string, mainRefFieldNames []string, allModels []modelStruct) {
 				group.Go(func() (err error) {
 					defer func() {
-						if panicErr := recovery(); panicErr != nil {
-							err = panicErr
+						if panicErr := recovery(recover()); panicErr != nil {
+							err = &MappingError{Model: meta.modelType.String(), Field: refField, Row: -1, Err: panicErr}
 						}
 					}()
 					pkFieldRefDataField := mainElem.Elem().FieldByName(refField)
@@ -362,16 +443,20 @@ func bindReference(ctx context.Context, mainElem reflect.Value, mainRefFieldName
 						pkFieldRefDataField.Set(reflect.MakeSlice(pkFieldRefDataField.Type(), 0, 0))
 					}
 
-					if tagVal := getTagValue(faith, refField, TAG_FK); tagVal != "" {
-						fk := newForeignKeyFromTag(tagVal)
-						if err := fk.Validate(); err != nil {
-							return err
-						}
+					if fk, ok := meta.fks[refField]; ok {
 						refModel := modelStructs(allModels).GetRefModelByFieldName(refField)
 						if !refModel.IsZero() && refModel.modelSlice.Len() > 0 {
-							for i := 0; i < refModel.modelSlice.Len(); i++ {
-								refVal := copy(refModel.modelSlice.Index(i))
-								if isJoin(faith, refVal.Interface(), fk.fkField1, fk.fkField2) {
+							parentFields, err := meta.getFields(fk.fkField1)
+							if err != nil {
+								return err
+							}
+							childFields, err := refModel.meta.getFields(fk.fkField2)
+							if err != nil {
+								return err
+							}
+							for _, i := range refModel.joinCandidates(mainElem, parentFields, childFields, options) {
+								if isJoin(mainElem, refModel.modelSlice.Index(i), parentFields, childFields, refModel.nulls, options) {
+									refVal := copy(refModel.modelSlice.Index(i))
 									if pkFieldRefDataField.Type().Kind() == reflect.Ptr {
 										/* object */
 										pkFieldRefDataField = refVal
@@ -382,6 +467,11 @@ func bindReference(ctx context.Context, mainElem reflect.Value, mainRefFieldName
 									}
 								}
 							}
+							if pkFieldRefDataField.Type().Kind() == reflect.Slice {
+								if err := sortModels(pkFieldRefDataField, refModel.meta, meta.fieldByName[refField].orderBy); err != nil {
+									return err
+								}
+							}
 						}
 					}
 					faith.Field(refField).Set(pkFieldRefDataField.Interface())
@@ -396,66 +486,24 @@ func bindReference(ctx context.Context, mainElem reflect.Value, mainRefFieldName
 	return nil
 }
 
-func isJoin(mainFaith *structs.Struct, refData interface{}, fkCol1Keys []string, fkCol2Keys []string) bool {
-	checkEqual := func(fkCol1, fkCol2 string) bool {
-		parentID := mainFaith.Field(fkCol1).Value()
-		parentType := mainFaith.Field(fkCol1).Tag(TAG_TYPE)
-		linkID := structs.New(refData).Field(fkCol2).Value()
-		return equal(parentType, parentID, linkID)
+func isJoin(mainElem reflect.Value, refElem reflect.Value, parentFields []*fieldMeta, childFields []*fieldMeta, nulls *nullFields, options MapperOption) bool {
+	/* NULL key never join, even when zero value is equal */
+	if nulls.isNull(mainElem, parentFields) || nulls.isNull(refElem, childFields) {
+		return false
 	}
-	var totalValid = len(fkCol1Keys)
+	checkEqual := func(parentField, childField *fieldMeta) bool {
+		registry, err := parentField.getRegistry(options)
+		if err != nil {
+			return false
+		}
+		return equal(registry, parentField.value(mainElem), childField.value(refElem))
+	}
+	var totalValid = len(parentFields)
 	var isValid int
-	for index, _ := range fkCol1Keys {
-		if checkEqual(fkCol1Keys[index], fkCol2Keys[index]) {
+	for index := range parentFields {
+		if checkEqual(parentFields[index], childFields[index]) {
 			isValid++
 		}
 	}
 	return totalValid == isValid
 }
-
-func addIteration(mapper *Mapper, options MapperOption, columns []*sql.ColumnType, columnM *hashmap.Map, values []interface{}) error {
-	if options.copyIntoIteration {
-		switch options.iterTypes {
-		case ITERATION_TYPE_LIST:
-			arr := arraylist.New(values...)
-			mapper.arrayListValues = append(mapper.arrayListValues, arr)
-		case ITERATION_TYPE_HASH_MAP:
-			if len(options.pkIterMapKeys) > 0 {
-				var valKey []string
-				for _, column := range options.pkIterMapKeys {
-					colIndex, found := columnM.Get(column)
-					if !found {
-						return fmt.Errorf("column %s not found from rows", column)
-					}
-					val := values[cast.ToInt(colIndex)]
-					if val == nil || val == "" {
-						continue
-					}
-					valKey = append(valKey, cast.ToString(val))
-				}
-				if len(options.pkIterMapKeys) == len(valKey) && len(valKey) > 0 {
-					key := strings.Join(valKey, fieldJoinKeyMap)
-					var data = hashmap.New()
-					for _, column := range columnM.Keys() {
-						switch len(options.mapStoreColumn) {
-						case 0:
-							colIndex, _ := columnM.Get(column)
-							data.Put(column, values[cast.ToInt(colIndex)])
-						default:
-							if len(options.mapStoreColumn) > 0 {
-								for _, storeColumn := range options.mapStoreColumn {
-									if _, ok := columnM.Get(storeColumn); ok {
-										colIndex, _ := columnM.Get(column)
-										data.Put(column, values[cast.ToInt(colIndex)])
-									}
-								}
-							}
-						}
-					}
-					mapper.hashMapValues.Put(key, data)
-				}
-			}
-		}
-	}
-	return nil
-}
@@ -0,0 +1,72 @@
+package orm_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/Pheethy/psql/orm"
+	"github.com/Pheethy/sqlx"
+	"gopkg.in/DATA-DOG/go-sqlmock.v2"
+)
+
+func BenchmarkOrm(b *testing.B) {
+	bu, err := ioutil.ReadFile("../testdata/orders.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	var orders = []*Order{}
+	if err := json.Unmarshal(bu, &orders); err != nil {
+		b.Fatal(err)
+	}
+
+	var columns = []string{
+		"orders.id", "orders.type", "orders.name", "orders.ppu", "orders.status", "orders.enable", "orders.order_date", "orders.created_at",
+		"toppings.id", "toppings.type", "toppings.order_id",
+	}
+	var newRows = func(b *testing.B, repeat int) *sqlx.Rows {
+		db, dbmock, err := sqlmock.New()
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Cleanup(func() { db.Close() })
+		rows := sqlmock.NewRows(columns)
+		for index := 0; index < repeat; index++ {
+			for _, order := range orders {
+				for _, topping := range order.Toppings {
+					rows.AddRow(
+						order.ID, order.Type, order.Name, order.Ppu, order.Status, order.Enable, order.OrderDate.String(), order.CreatedAt.String(),
+						topping.ID+index*len(order.Toppings)*10, topping.Type, order.ID,
+					)
+				}
+			}
+		}
+		dbmock.ExpectQuery(`SELECT (.+) orders`).WillReturnRows(rows)
+		sqlxRows, err := sqlx.NewDb(db, "sqlmock").Queryx(`SELECT * FROM orders`)
+		if err != nil {
+			b.Fatal(err)
+		}
+		return sqlxRows
+	}
+
+	for _, bench := range []struct {
+		name   string
+		repeat int
+	}{
+		{name: "rows_x1", repeat: 1},
+		{name: "rows_x100", repeat: 100},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				b.StopTimer()
+				rows := newRows(b, bench.repeat)
+				b.StartTimer()
+				if _, err := orm.Orm(new(Order), rows, orm.NewMapperOption()); err != nil {
+					b.Fatal(err)
+				}
+				rows.Close()
+			}
+		})
+	}
+}
@@ -3,14 +3,23 @@ package orm
 import (
 	"fmt"
 
+	"github.com/Pheethy/sqlx"
 	"github.com/fatih/structs"
 )
 
 type IterationTypes string
+type StrictModes string
+type SoftDeleteScopes string
 
 const (
 	ITERATION_TYPE_LIST     IterationTypes = "array_list"
 	ITERATION_TYPE_HASH_MAP IterationTypes = "hash_map"
+
+	STRICT_MODE_ERROR   StrictModes = "error"
+	STRICT_MODE_WARNING StrictModes = "warning"
+
+	SOFT_DELETE_SCOPE_WITH SoftDeleteScopes = "with_deleted"
+	SOFT_DELETE_SCOPE_ONLY SoftDeleteScopes = "only_deleted"
 )
 
 type MapperOption struct {
@@ -18,8 +27,16 @@ type MapperOption struct {
 	pkFields          []MapperOptionPkField
 	copyIntoIteration bool
 	iterTypes         IterationTypes
-	pkIterMapKeys     []string // pk for map column with rows.Next()
-	mapStoreColumn    []string // choosestoreColumns
+	pkIterMapKeys     []string            // pk for map column with rows.Next()
+	mapStoreColumn    []string            // choosestoreColumns
+	registries        map[string]Registry // override globalRegistry for this mapper
+	strictMode        StrictModes         // check unmapped column, field and column type
+	preloads          []string            // relation field load by secondary query instead of join
+	preloader         sqlx.QueryerContext
+	softDeleteScope   SoftDeleteScopes // default skip soft deleted model
+	skipAfterFind     bool             // preload query, AfterFind is called by root
+	trackChanges      bool             // snapshot mapped models for Tracker
+	registryErr       error            // first invalid SetRegistry, returned by Orm and write helpers
 }
 
 type MapperOptionPkField struct {
@@ -34,6 +51,7 @@ func NewMapperOption() MapperOption {
 		pkFields:       make([]MapperOptionPkField, 0),
 		pkIterMapKeys:  make([]string, 0),
 		mapStoreColumn: make([]string, 0),
+		registries:     make(map[string]Registry),
 	}
 }
 
@@ -55,13 +73,14 @@ func (m MapperOption) SetOverridePKField(fields ...MapperOptionPkField) MapperOp
 	return m
 }
 
-// Copy rows.Next() -> ArrayList
+// Copy rows.Next() -> Mapper.GetIterationRows()
 func (m MapperOption) SetIterationList() MapperOption {
 	m.copyIntoIteration = true
 	m.iterTypes = ITERATION_TYPE_LIST
 	return m
 }
 
+// Copy rows.Next() -> Mapper.GetIterationRowsByKey() |
 // pkColumnName is column from query to get pk if data is null will be skip that rows |
 // storeOnlyColumn is optional choose store column
 // example []string{"orders.id"}, nil
@@ -76,20 +95,18 @@ func (m MapperOption) SetIterationHashMapWithColumns(pkColumnName []string, stor
 }
 
 /*
+Copy rows.Next() -> Mapper.GetIterationRowsByKey() |
 model is column from query to get pk if data is null will be skip that rows |
 storeOnlyColumn is optional choose store column
 example new(Order), nil
 */
 func (m MapperOption) SetIterationHashMapWithModel(model interface{}, storeColumns []string) MapperOption {
-	faith := structs.New(model)
-	tablename := getTableName(faith)
-	pkFields, _ := getFieldMetaData(faith, m)
 	pkColumnNames := []string{}
-	for index := range pkFields {
-		faith.Field(pkFields[index])
-		tagVal := getTagValue(faith, pkFields[index], TAGNAME)
-		if tagVal != "" && tagVal != "-" {
-			pkColumnNames = append(pkColumnNames, fmt.Sprintf("%s.%s", tablename, tagVal))
+	if meta, err := getModelMeta(model); err == nil {
+		for _, pkField := range meta.getPKFields(m) {
+			if field, ok := meta.fieldByName[pkField]; ok && field.column != "" {
+				pkColumnNames = append(pkColumnNames, fmt.Sprintf("%s.%s", meta.table, field.column))
+			}
 		}
 	}
 
@@ -101,3 +118,87 @@ func (m MapperOption) SetIterationHashMapWithModel(model interface{}, storeColum
 	}
 	return m
 }
+
+/*
+SetRegistry override registry of `type:"name"` tag only for this mapper
+without touch globalRegistry, useful for test or tenant specific type.
+invalid name or registry is checked same as RegisterType and returned by Orm
+*/
+func (m MapperOption) SetRegistry(name string, registry Registry) MapperOption {
+	if err := validateRegistry(name, registry); err != nil {
+		if m.registryErr == nil {
+			m.registryErr = err
+		}
+		return m
+	}
+	var registries = make(map[string]Registry, len(m.registries)+1)
+	for key, val := range m.registries {
+		registries[key] = val
+	}
+	registries[name] = registry
+	m.registries = registries
+	return m
+}
+
+func (m MapperOption) getRegistry(name string) (Registry, bool) {
+	if registry, ok := m.registries[name]; ok {
+		return registry, true
+	}
+	return lookupRegistry(name)
+}
+
+/*
+SetStrict fail mapping when column of rows is not mapped to any field,
+db field of selected model never receive column or column type is incompatible with registry
+*/
+func (m MapperOption) SetStrict() MapperOption {
+	m.strictMode = STRICT_MODE_ERROR
+	return m
+}
+
+// SetStrictWarning same check as SetStrict but collect into Mapper.GetWarnings()
+func (m MapperOption) SetStrictWarning() MapperOption {
+	m.strictMode = STRICT_MODE_WARNING
+	return m
+}
+
+/*
+SetPreload load relation field (`fk` tag) with one query per relation after root rows is mapped
+(`WHERE fk = ANY($1)`) instead of join into root query, root query select only root columns.
+nested relation with dot, example SetPreload(db, "Toppings", "Toppings.Items")
+*/
+func (m MapperOption) SetPreload(queryer sqlx.QueryerContext, relations ...string) MapperOption {
+	m.preloader = queryer
+	m.preloads = append(append(make([]string, 0, len(m.preloads)+len(relations)), m.preloads...), relations...)
+	return m
+}
+
+func (m MapperOption) isPreload(field string) bool {
+	for _, relation := range groupPreloads(m.preloads) {
+		if relation.field == field {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+SetTrackChanges snapshot value of every mapped model (root and relation),
+read diff with mapper.GetTracker().Changes(model) and write only changed column with UpdateChanged
+*/
+func (m MapperOption) SetTrackChanges() MapperOption {
+	m.trackChanges = true
+	return m
+}
+
+// SetWithDeleted keep soft deleted root and relation (`softdelete:"true"` field is not null)
+func (m MapperOption) SetWithDeleted() MapperOption {
+	m.softDeleteScope = SOFT_DELETE_SCOPE_WITH
+	return m
+}
+
+// SetOnlyDeleted keep only soft deleted root, deleted relation is still skipped
+func (m MapperOption) SetOnlyDeleted() MapperOption {
+	m.softDeleteScope = SOFT_DELETE_SCOPE_ONLY
+	return m
+}
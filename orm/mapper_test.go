@@ -24,7 +24,7 @@ type Order struct {
 	Status    int                    `json:"status" db:"status" type:"int32"`
 	Enable    bool                   `json:"enable" db:"enable" type:"bool"`
 	OrderDate *helperModel.Date      `json:"order_date" db:"order_date" type:"date"`
-	CreatedAt *helperModel.Timestamp `json:"created_at" db:"created_at" type:"timestamp"`
+	CreatedAt *helperModel.Timestamp `json:"created_at" db:"created_at" type:"timestamp" default:"true"`
 	ChefID    helperModel.ZeroUUID   `json:"-" db:"chef_id" type:"zerouuid"`
 
 	Chef     *Chef      `json:"chef" db:"-" fk:"fk_field1:ChefID,fk_field2:ID"`
@@ -0,0 +1,376 @@
+package orm
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fatih/structs"
+)
+
+/*
+modelMeta is immutable metadata of model struct type,
+compute once per reflect.Type and share between mapper
+*/
+type modelMeta struct {
+	modelType   reflect.Type // struct type, not pointer
+	name        string       // same as structs.Struct Name()
+	table       string
+	fields      []*fieldMeta
+	fieldByName map[string]*fieldMeta
+	columnMap   map[string]*fieldMeta // db column -> field
+	pkFields    []string
+	fkFields    []string
+	fks         map[string]foreignKey // fk field name -> foreign key
+	softDelete  *fieldMeta            // field with `softdelete:"true"`, nil when model is hard delete
+	createTime  *fieldMeta            // field with `autoCreateTime:"true"`
+	updateTime  *fieldMeta            // field with `autoUpdateTime:"true"`
+	version     *fieldMeta            // field with `version:"true"`, optimistic lock of Update
+	audit       bool                  // TableName with `audit:"true"`, write is recorded into AUDIT_TABLE_NAME
+}
+
+type fieldMeta struct {
+	name      string
+	index     []int
+	fieldType reflect.Type
+	column    string       // db tag, empty when not a column
+	typeTag   string       // type tag
+	fkTag     string       // fk tag
+	inferred  Registry     // registry from go type when type tag is absent
+	orderBy   []orderField // order tag of relation field
+	dbDefault bool         // `default:"true"`, nil or zero value is inserted as DEFAULT
+}
+
+var modelMetaCache sync.Map // reflect.Type -> *modelMeta
+
+// getModelMeta accept struct, pointer of struct or their reflect.Type
+func getModelMeta(model interface{}) (*modelMeta, error) {
+	var modelType reflect.Type
+	switch v := model.(type) {
+	case reflect.Type:
+		modelType = v
+	default:
+		modelType = reflect.TypeOf(model)
+	}
+	for modelType != nil && modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+	if modelType == nil {
+		return nil, ErrMustNotNil
+	}
+	if modelType.Kind() != reflect.Struct {
+		return nil, ErrMustBeStruct
+	}
+
+	if meta, ok := modelMetaCache.Load(modelType); ok {
+		return meta.(*modelMeta), nil
+	}
+	meta, err := newModelMeta(modelType)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := modelMetaCache.LoadOrStore(modelType, meta)
+	return actual.(*modelMeta), nil
+}
+
+func newModelMeta(modelType reflect.Type) (*modelMeta, error) {
+	meta := &modelMeta{
+		modelType:   modelType,
+		name:        modelType.Name(),
+		fields:      make([]*fieldMeta, 0, modelType.NumField()),
+		fieldByName: make(map[string]*fieldMeta, modelType.NumField()),
+		columnMap:   make(map[string]*fieldMeta, modelType.NumField()),
+		pkFields:    make([]string, 0),
+		fkFields:    make([]string, 0),
+		fks:         make(map[string]foreignKey),
+	}
+
+	for _, modelField := range getModelFields(modelType) {
+		structField := modelField.StructField
+		field := &fieldMeta{
+			name:      structField.Name,
+			index:     structField.Index,
+			fieldType: structField.Type,
+			typeTag:   strings.TrimSpace(structField.Tag.Get(TAG_TYPE)),
+			fkTag:     strings.TrimSpace(structField.Tag.Get(TAG_FK)),
+		}
+		meta.fields = append(meta.fields, field)
+		meta.fieldByName[field.name] = field
+
+		if field.name == TABLE_FIELD_NAME {
+			meta.table = structField.Tag.Get(TAGNAME)
+			pkTag := strings.TrimSpace(structField.Tag.Get(TAG_PK))
+			meta.pkFields = strings.Split(pkTag, fieldSeperate)
+			meta.audit = isTagEnabled(structField, TAG_AUDIT)
+			continue
+		}
+		if modelField.column != "" {
+			field.column = modelField.column
+			meta.columnMap[field.column] = field
+			if isSoftDeleteField(structField) && meta.softDelete == nil {
+				meta.softDelete = field
+			}
+			if isTagEnabled(structField, TAG_AUTO_CREATE_TIME) && meta.createTime == nil {
+				meta.createTime = field
+			}
+			if isTagEnabled(structField, TAG_AUTO_UPDATE_TIME) && meta.updateTime == nil {
+				meta.updateTime = field
+			}
+			if isTagEnabled(structField, TAG_VERSION) && meta.version == nil {
+				meta.version = field
+			}
+			field.dbDefault = isTagEnabled(structField, TAG_DEFAULT)
+		}
+		if field.typeTag == "" {
+			field.inferred, _ = inferRegistry(field.fieldType)
+		}
+		if field.fkTag != "" && len(field.index) == 1 {
+			fk := newForeignKeyFromTag(field.fkTag)
+			if err := fk.Validate(); err != nil {
+				return nil, &MappingError{Model: modelType.String(), Field: field.name, Row: -1, Err: err}
+			}
+			orderBy, err := parseOrderTag(strings.TrimSpace(structField.Tag.Get(TAG_ORDER)))
+			if err != nil {
+				return nil, &MappingError{Model: modelType.String(), Field: field.name, Row: -1, Err: err}
+			}
+			field.orderBy = orderBy
+			meta.fkFields = append(meta.fkFields, field.name)
+			meta.fks[field.name] = fk
+		}
+	}
+	if len(meta.pkFields) == 0 {
+		meta.pkFields = []string{""}
+	}
+
+	return meta, nil
+}
+
+/*
+modelField is exported field of model, embedded struct is flattened.
+StructField.Index is full path from model, column is db tag with prefix of embedded struct
+*/
+type modelField struct {
+	reflect.StructField
+	column string
+}
+
+/*
+getModelFields flatten embedded struct (value or pointer) in place,
+embedded struct is flattened when it has no db and fk tag and is not a value type (time.Time, sql.Scanner).
+column of embedded field can be prefixed by `prefix:"audit_"` on embedded field.
+field of outer struct shadow embedded field with the same name like go promoted field
+*/
+func getModelFields(modelType reflect.Type) []modelField {
+	return collectModelFields(modelType, nil, "", make(map[string]bool), map[reflect.Type]bool{modelType: true})
+}
+
+func collectModelFields(structType reflect.Type, parentIndex []int, prefix string, shadow map[string]bool, visiting map[reflect.Type]bool) []modelField {
+	var fields = make([]modelField, 0, structType.NumField())
+	/* name of this level shadow deeper embedded field */
+	var levelShadow = make(map[string]bool, len(shadow)+structType.NumField())
+	for name := range shadow {
+		levelShadow[name] = true
+	}
+	for index := 0; index < structType.NumField(); index++ {
+		if field := structType.Field(index); field.PkgPath == "" && !isFlattenEmbedded(field) {
+			levelShadow[field.Name] = true
+		}
+	}
+
+	for index := 0; index < structType.NumField(); index++ {
+		field := structType.Field(index)
+		if field.PkgPath != "" && (!field.Anonymous || field.Type.Kind() == reflect.Ptr) {
+			/* unexported pointer embedded can not be allocated */
+			continue
+		}
+		field.Index = append(append([]int{}, parentIndex...), index)
+
+		if isFlattenEmbedded(field) {
+			embeddedType := field.Type
+			if embeddedType.Kind() == reflect.Ptr {
+				embeddedType = embeddedType.Elem()
+			}
+			if visiting[embeddedType] {
+				continue
+			}
+			visiting[embeddedType] = true
+			fields = append(fields, collectModelFields(embeddedType, field.Index, prefix+field.Tag.Get(TAG_PREFIX), levelShadow, visiting)...)
+			delete(visiting, embeddedType)
+			continue
+		}
+		if field.PkgPath != "" || shadow[field.Name] {
+			continue
+		}
+		if len(parentIndex) > 0 && (field.Name == TABLE_FIELD_NAME || field.Tag.Get(TAG_FK) != "") {
+			/* TableName and relation only from model itself */
+			continue
+		}
+
+		var column string
+		if tag := field.Tag.Get(TAGNAME); tag != "" && tag != "-" {
+			column = prefix + tag
+		}
+		fields = append(fields, modelField{StructField: field, column: column})
+	}
+	return fields
+}
+
+func isSoftDeleteField(field reflect.StructField) bool {
+	softDelete, _ := strconv.ParseBool(strings.TrimSpace(field.Tag.Get(TAG_SOFT_DELETE)))
+	return softDelete
+}
+
+func isFlattenEmbedded(field reflect.StructField) bool {
+	if !field.Anonymous || field.Tag.Get(TAGNAME) != "" || field.Tag.Get(TAG_FK) != "" {
+		return false
+	}
+	embeddedType := field.Type
+	if embeddedType.Kind() == reflect.Ptr {
+		embeddedType = embeddedType.Elem()
+	}
+	if embeddedType.Kind() != reflect.Struct || embeddedType == timeType {
+		return false
+	}
+	return !reflect.PtrTo(embeddedType).Implements(scannerType)
+}
+
+// getPKFields return pk from TableName tag or override from MapperOption
+func (m *modelMeta) getPKFields(options MapperOption) []string {
+	for _, pkField := range options.pkFields {
+		if pkField.faith.Name() == m.name {
+			return pkField.fieldName
+		}
+	}
+	return m.pkFields
+}
+
+func (m *modelMeta) getFields(names []string) ([]*fieldMeta, error) {
+	var fields = make([]*fieldMeta, 0, len(names))
+	for _, name := range names {
+		field, ok := m.fieldByName[name]
+		if !ok {
+			return nil, &MappingError{Model: m.modelType.String(), Field: name, Row: -1, Err: ErrFieldNotFound}
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+/*
+planColumns match result columns with field once per query,
+column can be "tablename.column" or "column".
+when alias is set (alias of fk tag) only "alias.column" is matched
+*/
+func (m *modelMeta) planColumns(columns []*sql.ColumnType, alias string) []*fieldMeta {
+	var plan = make([]*fieldMeta, len(columns))
+	for index, col := range columns {
+		name := strings.ReplaceAll(col.Name(), m.table+".", "")
+		if alias != "" {
+			var ok bool
+			if name, ok = strings.CutPrefix(col.Name(), alias+"."); !ok {
+				continue
+			}
+		}
+		if field, ok := m.columnMap[name]; ok {
+			plan[index] = field
+		}
+	}
+	return plan
+}
+
+func (f *fieldMeta) getRegistry(options MapperOption) (Registry, error) {
+	switch f.typeTag {
+	case "-":
+		return nil, nil
+	case "":
+		return f.inferred, nil
+	}
+	registry, ok := options.getRegistry(f.typeTag)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrRegistryNotFound, f.typeTag)
+	}
+	return registry, nil
+}
+
+// value of field by reflect index, elem is pointer of model. zero value when embedded pointer is nil
+func (f *fieldMeta) value(elem reflect.Value) interface{} {
+	val, ok := f.lookup(elem)
+	if !ok {
+		return reflect.Zero(f.fieldType).Interface()
+	}
+	return val
+}
+
+// lookup return false when some embedded pointer on the way is nil
+func (f *fieldMeta) lookup(elem reflect.Value) (interface{}, bool) {
+	val, err := reflect.Indirect(elem).FieldByIndexErr(f.index)
+	if err != nil {
+		return nil, false
+	}
+	return val.Interface(), true
+}
+
+/*
+structField return field for Registry.Bind,
+nil embedded pointer on the way is allocated. faith is struct of elem
+*/
+func (f *fieldMeta) structField(faith *structs.Struct, elem reflect.Value) *structs.Field {
+	if len(f.index) == 1 {
+		return faith.Field(f.name)
+	}
+	parent := reflect.Indirect(elem)
+	for _, index := range f.index[:len(f.index)-1] {
+		parent = parent.Field(index)
+		if parent.Kind() == reflect.Ptr {
+			if parent.IsNil() {
+				parent.Set(reflect.New(parent.Type().Elem()))
+			}
+			parent = parent.Elem()
+		}
+	}
+	return structs.New(parent.Addr().Interface()).Field(f.name)
+}
+
+/*
+fillByPlan bind values into ptr with column plan from planColumns,
+error and panic of registry is return as *MappingError
+*/
+func fillByPlan(ptr interface{}, plan []*fieldMeta, columns []*sql.ColumnType, values []interface{}, row int, options MapperOption) error {
+	if len(values) == 0 {
+		return nil
+	}
+	faith := structs.New(ptr)
+	elem := reflect.ValueOf(ptr)
+	for index, field := range plan {
+		if field == nil {
+			continue
+		}
+		if err := bindField(faith, elem, field, values[index], options); err != nil {
+			return &MappingError{
+				Model:  reflect.TypeOf(ptr).Elem().String(),
+				Field:  field.name,
+				Column: columns[index].Name(),
+				Row:    row,
+				Value:  values[index],
+				Err:    err,
+			}
+		}
+	}
+	return nil
+}
+
+func bindField(faith *structs.Struct, elem reflect.Value, field *fieldMeta, val interface{}, options MapperOption) (err error) {
+	defer func() {
+		if panicErr := recovery(recover()); panicErr != nil {
+			err = panicErr
+		}
+	}()
+	registry, err := field.getRegistry(options)
+	if err != nil || registry == nil {
+		return err
+	}
+	return registry.Bind(field.structField(faith, elem), val)
+}
@@ -1,29 +1,40 @@
 package orm
 
 import (
+	"database/sql"
 	"reflect"
 	"strings"
 	"sync"
-
-	"github.com/fatih/structs"
 )
 
 type modelStruct struct {
-	fieldname        string        // fieldname ใช้สำหรับ reference ของ pk
-	name             string        // ชื่อ Type model
-	model            interface{}   // ค่าของ model เช่น new(models.User)
-	modelType        reflect.Type  // reflect.TypeOf(new(models.User))
-	modelSlice       reflect.Value // value reflect.Value( []*models.User)
-	pkM              *sync.Map     // map สำหรับทำ duplicate PK
-	pkFields         []string
-	refFields        []string // binding modelRef -> main
-	isReferenceModel bool
-	subRefModel      []modelStruct
+	fieldname            string        // fieldname ใช้สำหรับ reference ของ pk
+	name                 string        // ชื่อ Type model
+	model                interface{}   // ค่าของ model เช่น new(models.User)
+	modelType            reflect.Type  // reflect.TypeOf(new(models.User))
+	modelSlice           reflect.Value // value reflect.Value( []*models.User)
+	pkM                  *sync.Map     // map สำหรับทำ duplicate PK
+	pkFields             []string
+	refFields            []string // binding modelRef -> main
+	isReferenceModel     bool
+	subRefModel          []modelStruct
+	meta                 *modelMeta    // cached metadata of model type
+	keyFields            []*fieldMeta  // pk (+ refFields) for duplicate check
+	plan                 []*fieldMeta  // column index -> field, set once per query
+	keyColumns           []int         // column index of keyFields, -1 when not selected
+	isSelected           bool          // some column of query is mapped into model
+	joinIndex            *joinIndex    // index of modelSlice by fk value
+	alias                string        // alias of fk tag, column is "alias.column"
+	current              reflect.Value // model of current row, for iteration value
+	isRoot               bool          // main model of Orm, not main model of sub reference
+	isSoftDeleteSelected bool          // soft delete column is in query
+	nulls                *nullFields   // NULL field of filled model, shared by every model of query
+	hasDeleted           bool          // root of some row is filtered by isVisible
 }
 
 type modelStructs []modelStruct
 
-func newMainModelStruct(model interface{}, option MapperOption) modelStruct {
+func newMainModelStruct(model interface{}, meta *modelMeta, option MapperOption) (modelStruct, error) {
 	var modelType = reflect.TypeOf(model)
 	var ptrs = getEmptySlice(modelType, 10000)
 	ms := modelStruct{
@@ -33,18 +44,15 @@ func newMainModelStruct(model interface{}, option MapperOption) modelStruct {
 		modelSlice:       ptrs,
 		pkM:              new(sync.Map),
 		isReferenceModel: false,
-	}
-	if ms.IsMainModel() {
-		faith := structs.New(model)
-		pkFields, fkFields := getFieldMetaData(faith, option)
-
-		ms.pkFields = pkFields
-		ms.refFields = fkFields
+		meta:             meta,
+		pkFields:         meta.getPKFields(option),
+		refFields:        meta.fkFields,
+		joinIndex:        new(joinIndex),
 	}
 
-	return ms
+	return ms, ms.setKeyFields()
 }
-func newRefModelStruct(model interface{}, fieldName string, refFields []string) modelStruct {
+func newRefModelStruct(model interface{}, meta *modelMeta, fieldName string, fk foreignKey, option MapperOption) (modelStruct, error) {
 	var modelType = reflect.TypeOf(model)
 	var ptrs = getEmptySlice(modelType, 0)
 	ms := modelStruct{
@@ -55,55 +63,58 @@ func newRefModelStruct(model interface{}, fieldName string, refFields []string)
 		modelSlice:       ptrs,
 		pkM:              new(sync.Map),
 		isReferenceModel: true,
-		refFields:        refFields,
+		refFields:        fk.fkField2,
+		alias:            fk.alias,
 		subRefModel:      make([]modelStruct, 0),
+		meta:             meta,
+		pkFields:         meta.getPKFields(option),
+		joinIndex:        new(joinIndex),
 	}
 
-	return ms
+	return ms, ms.setKeyFields()
 }
 
 func newModelStruct(model interface{}, options MapperOption) ([]modelStruct, error) {
 	if err := validateModel(model); err != nil {
 		return nil, err
 	}
-	faithModel := structs.New(model)
-	_, fkFields := getFieldMetaData(faithModel, options)
-	mainModelStruct := newMainModelStruct(model, options)
+	meta, err := getModelMeta(model)
+	if err != nil {
+		return nil, err
+	}
+	mainModelStruct, err := newMainModelStruct(model, meta, options)
+	if err != nil {
+		return nil, err
+	}
 
 	var ms = make([]modelStruct, 0)
 	ms = append(ms, mainModelStruct)
 
 	/* add fk model */
-	if len(fkFields) > 0 && options.autobinding {
-		for _, field := range fkFields {
-			val, err := getFieldValue(faithModel, field)
-			if err != nil {
-				return nil, err
-			}
+	if len(meta.fkFields) > 0 && options.autobinding {
+		for _, field := range meta.fkFields {
 			var elem reflect.Value
-
-			if reflect.ValueOf(val).Kind() == reflect.Ptr {
+			fieldType := meta.fieldByName[field].fieldType
+			if fieldType.Kind() == reflect.Ptr {
 				/* pointer Object */
-				types := reflect.ValueOf(val).Type()
-				elem = reflect.New(types.Elem())
+				elem = reflect.New(fieldType.Elem())
 			} else {
 				/* slice */
-				elemType := reflect.TypeOf(val).Elem()
-				elem = reflect.New(elemType.Elem())
+				elem = reflect.New(fieldType.Elem().Elem())
 			}
 
 			if err := validateModel(elem.Interface()); err != nil {
 				return nil, err
 			}
-
-			if tagVal := getTagValue(faithModel, field, TAG_FK); tagVal != "" {
-				fk := newForeignKeyFromTag(tagVal)
-				if err := fk.Validate(); err != nil {
-					return nil, err
-				}
-
-				ms = append(ms, newRefModelStruct(elem.Interface(), field, fk.fkField2))
+			refMeta, err := getModelMeta(elem.Type())
+			if err != nil {
+				return nil, err
 			}
+			refModel, err := newRefModelStruct(elem.Interface(), refMeta, field, meta.fks[field], options)
+			if err != nil {
+				return nil, err
+			}
+			ms = append(ms, refModel)
 		}
 	}
 
@@ -147,3 +158,117 @@ func (m modelStructs) GetRefModelByFieldName(fieldName string) modelStruct {
 	}
 	return modelStruct{}
 }
+
+// setKeyFields pk for main model, pk + refFields for reference model
+func (m *modelStruct) setKeyFields() error {
+	var names = m.pkFields
+	if !m.IsMainModel() {
+		names = append(append([]string{}, m.pkFields...), m.refFields...)
+	}
+	keyFields, err := m.meta.getFields(names)
+	if err != nil {
+		return err
+	}
+	m.keyFields = keyFields
+	return nil
+}
+
+// setPlan match query columns with field of model and sub reference model
+func (m *modelStruct) setPlan(columns []*sql.ColumnType, nulls *nullFields) {
+	m.plan = m.meta.planColumns(columns, m.alias)
+	m.nulls = nulls
+	m.isSelected = false
+	m.isSoftDeleteSelected = false
+	m.keyColumns = make([]int, len(m.keyFields))
+	for index := range m.keyColumns {
+		m.keyColumns[index] = -1
+	}
+	for columnIndex, field := range m.plan {
+		if field == nil {
+			continue
+		}
+		m.isSelected = true
+		if field == m.meta.softDelete {
+			m.isSoftDeleteSelected = true
+		}
+		for keyIndex, keyField := range m.keyFields {
+			if keyField == field && m.keyColumns[keyIndex] == -1 {
+				m.keyColumns[keyIndex] = columnIndex
+			}
+		}
+	}
+	for index := range m.subRefModel {
+		m.subRefModel[index].setPlan(columns, nulls)
+	}
+}
+
+/*
+joinIndex group position of modelSlice by fk value (RegisterPkId of parent registry),
+build once after every rows is filled. keys is nil when some value can not be keyed,
+then every position is candidate like before
+*/
+type joinIndex struct {
+	once sync.Once
+	keys map[string][]int
+}
+
+func (m modelStruct) joinCandidates(mainElem reflect.Value, parentFields []*fieldMeta, childFields []*fieldMeta, options MapperOption) []int {
+	m.joinIndex.once.Do(func() {
+		var keys = make(map[string][]int, m.modelSlice.Len())
+		for index := 0; index < m.modelSlice.Len(); index++ {
+			if m.nulls.isNull(m.modelSlice.Index(index), childFields) {
+				continue
+			}
+			key, ok := joinKey(m.modelSlice.Index(index), parentFields, childFields, options)
+			if !ok {
+				return
+			}
+			if key != "" {
+				keys[key] = append(keys[key], index)
+			}
+		}
+		m.joinIndex.keys = keys
+	})
+
+	if m.nulls.isNull(mainElem, parentFields) {
+		return nil
+	}
+	if m.joinIndex.keys != nil {
+		if key, ok := joinKey(mainElem, parentFields, parentFields, options); ok {
+			if key == "" {
+				return nil
+			}
+			return m.joinIndex.keys[key]
+		}
+	}
+	var all = make([]int, m.modelSlice.Len())
+	for index := range all {
+		all[index] = index
+	}
+	return all
+}
+
+/*
+joinKey use registry of parent field for both side, so key is comparable with Equal.
+key is "" when some component is null, never join
+*/
+func joinKey(elem reflect.Value, parentFields []*fieldMeta, fields []*fieldMeta, options MapperOption) (key string, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			key, ok = "", false
+		}
+	}()
+	var ids = make([]string, 0, len(fields))
+	for index, field := range fields {
+		registry, err := parentFields[index].getRegistry(options)
+		if err != nil || registry == nil {
+			return "", false
+		}
+		val := field.value(elem)
+		if isNullValue(registry, val) {
+			return "", true
+		}
+		ids = append(ids, registry.RegisterPkId(val))
+	}
+	return joinIds(ids), true
+}
@@ -0,0 +1,185 @@
+package orm
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+orderField is one item of `order` tag on relation field
+example `order:"Type,ID desc"` sort children by Type then ID descending
+*/
+type orderField struct {
+	name string
+	desc bool
+}
+
+func parseOrderTag(tag string) ([]orderField, error) {
+	if tag == "" {
+		return nil, nil
+	}
+	var orders = make([]orderField, 0)
+	for _, item := range strings.Split(tag, fieldSeperate) {
+		words := strings.Fields(item)
+		if len(words) == 0 || len(words) > 2 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidOrderTag, tag)
+		}
+		order := orderField{name: words[0]}
+		if len(words) == 2 {
+			switch strings.ToLower(words[1]) {
+			case "asc":
+			case "desc":
+				order.desc = true
+			default:
+				return nil, fmt.Errorf("%w: %q", ErrInvalidOrderTag, tag)
+			}
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+/*
+sortModels stable sort slice of model pointer by orderBy,
+equal value keep first seen row order
+*/
+func sortModels(slice reflect.Value, meta *modelMeta, orderBy []orderField) error {
+	if len(orderBy) == 0 || slice.Len() < 2 {
+		return nil
+	}
+	var names = make([]string, 0, len(orderBy))
+	for _, order := range orderBy {
+		names = append(names, order.name)
+	}
+	fields, err := meta.getFields(names)
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(slice.Interface(), func(i, j int) bool {
+		for index, field := range fields {
+			cmp := compareValue(field.value(slice.Index(i)), field.value(slice.Index(j)))
+			if cmp == 0 {
+				continue
+			}
+			if orderBy[index].desc {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+	return nil
+}
+
+/*
+compareValue compare scalar, time.Time and driver.Valuer value,
+nil is less than any value
+*/
+func compareValue(x interface{}, y interface{}) int {
+	x, y = orderValue(x), orderValue(y)
+	switch {
+	case x == nil && y == nil:
+		return 0
+	case x == nil:
+		return -1
+	case y == nil:
+		return 1
+	}
+
+	if xTime, ok := x.(time.Time); ok {
+		if yTime, ok := y.(time.Time); ok {
+			switch {
+			case xTime.Before(yTime):
+				return -1
+			case xTime.After(yTime):
+				return 1
+			}
+			return 0
+		}
+	}
+
+	xVal, yVal := reflect.ValueOf(x), reflect.ValueOf(y)
+	switch {
+	case isIntKind(xVal.Kind()) && isIntKind(yVal.Kind()):
+		return compareInt(xVal.Int(), yVal.Int())
+	case isUintKind(xVal.Kind()) && isUintKind(yVal.Kind()):
+		switch {
+		case xVal.Uint() < yVal.Uint():
+			return -1
+		case xVal.Uint() > yVal.Uint():
+			return 1
+		}
+		return 0
+	case isNumberKind(xVal.Kind()) && isNumberKind(yVal.Kind()):
+		return compareFloat(toFloat(xVal), toFloat(yVal))
+	case xVal.Kind() == reflect.Bool && yVal.Kind() == reflect.Bool:
+		return compareFloat(toFloat(xVal), toFloat(yVal))
+	}
+	return strings.Compare(fmt.Sprint(x), fmt.Sprint(y))
+}
+
+// orderValue indirect pointer and use driver value of custom type (helper.Date, sql.Null*, ...)
+func orderValue(val interface{}) interface{} {
+	if valuer, ok := val.(driver.Valuer); ok {
+		if isNil(val) {
+			return nil
+		}
+		driverVal, err := valuer.Value()
+		if err == nil {
+			return driverVal
+		}
+	}
+	return indirect(val)
+}
+
+func isIntKind(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Int64
+}
+
+func isUintKind(kind reflect.Kind) bool {
+	return kind >= reflect.Uint && kind <= reflect.Uint64
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	return isIntKind(kind) || isUintKind(kind) || kind == reflect.Float32 || kind == reflect.Float64
+}
+
+func toFloat(val reflect.Value) float64 {
+	switch {
+	case isIntKind(val.Kind()):
+		return float64(val.Int())
+	case isUintKind(val.Kind()):
+		return float64(val.Uint())
+	case val.Kind() == reflect.Bool:
+		if val.Bool() {
+			return 1
+		}
+		return 0
+	}
+	return val.Float()
+}
+
+func compareFloat(x float64, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+func compareInt(x int64, y int64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,110 @@
+package orm_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Pheethy/psql/orm"
+	"github.com/Pheethy/sqlx"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v2"
+)
+
+type Menu struct {
+	TableName struct{}    `json:"-" db:"menus" pk:"ID"`
+	ID        int         `json:"id" db:"id"`
+	Name      string      `json:"name" db:"name"`
+	Items     []*MenuItem `json:"items" db:"-" fk:"fk_field1:ID,fk_field2:MenuID"`
+}
+
+type SortedMenu struct {
+	TableName struct{}    `json:"-" db:"menus" pk:"ID"`
+	ID        int         `json:"id" db:"id"`
+	Name      string      `json:"name" db:"name"`
+	Items     []*MenuItem `json:"items" db:"-" fk:"fk_field1:ID,fk_field2:MenuID" order:"Type,Price desc"`
+}
+
+type InvalidSortedMenu struct {
+	TableName struct{}    `json:"-" db:"menus" pk:"ID"`
+	ID        int         `json:"id" db:"id"`
+	Items     []*MenuItem `json:"items" db:"-" fk:"fk_field1:ID,fk_field2:MenuID" order:"Type upward"`
+}
+
+type MenuItem struct {
+	TableName struct{} `json:"-" db:"menu_items" pk:"ID"`
+	ID        int      `json:"id" db:"id"`
+	Type      string   `json:"type" db:"type"`
+	Price     *float64 `json:"price" db:"price"`
+	MenuID    int      `json:"menu_id" db:"menu_id"`
+}
+
+func TestOrder(t *testing.T) {
+	var columns = []string{"menus.id", "menus.name", "menu_items.id", "menu_items.type", "menu_items.price", "menu_items.menu_id"}
+	var newRows = func(t *testing.T) *sqlx.Rows {
+		db, dbmock, err := sqlmock.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		rows := sqlmock.NewRows(columns).
+			AddRow(3, "lunch", 30, "main", 120.0, 3).
+			AddRow(1, "breakfast", 12, "drink", 40.0, 1).
+			AddRow(3, "lunch", 10, "drink", 25.0, 3).
+			AddRow(1, "breakfast", 11, "main", nil, 1).
+			AddRow(3, "lunch", 20, "main", 150.0, 3).
+			AddRow(2, "dinner", nil, nil, nil, nil).
+			AddRow(1, "breakfast", 13, "drink", 45.0, 1)
+		dbmock.ExpectQuery(`SELECT (.+) menus`).WillReturnRows(rows)
+		sqlxRows, err := sqlx.NewDb(db, "sqlmock").Queryx(`SELECT * FROM menus`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sqlxRows
+	}
+	var itemIDs = func(items []*MenuItem) []int {
+		var ids = make([]int, 0, len(items))
+		for _, item := range items {
+			ids = append(ids, item.ID)
+		}
+		return ids
+	}
+
+	t.Run("success_keep_first_seen_row_order", func(t *testing.T) {
+		for attempt := 0; attempt < 20; attempt++ {
+			rows := newRows(t)
+			mapper, err := orm.Orm(new(Menu), rows, orm.NewMapperOption())
+			rows.Close()
+			assert.NoError(t, err)
+
+			menus := mapper.GetData().([]*Menu)
+			assert.Len(t, menus, 3)
+			assert.Equal(t, []int{3, 1, 2}, []int{menus[0].ID, menus[1].ID, menus[2].ID})
+			assert.Equal(t, []int{30, 10, 20}, itemIDs(menus[0].Items))
+			assert.Equal(t, []int{12, 11, 13}, itemIDs(menus[1].Items))
+			assert.Len(t, menus[2].Items, 0)
+		}
+	})
+
+	t.Run("success_sort_children_by_order_tag", func(t *testing.T) {
+		rows := newRows(t)
+		defer rows.Close()
+		mapper, err := orm.Orm(new(SortedMenu), rows, orm.NewMapperOption())
+		assert.NoError(t, err)
+
+		menus := mapper.GetData().([]*SortedMenu)
+		assert.Len(t, menus, 3)
+		/* root still row order */
+		assert.Equal(t, []int{3, 1, 2}, []int{menus[0].ID, menus[1].ID, menus[2].ID})
+		/* Type asc, Price desc */
+		assert.Equal(t, []int{10, 20, 30}, itemIDs(menus[0].Items))
+		/* nil price is less than any value */
+		assert.Equal(t, []int{13, 12, 11}, itemIDs(menus[1].Items))
+	})
+
+	t.Run("error_invalid_order_tag", func(t *testing.T) {
+		rows := newRows(t)
+		defer rows.Close()
+		_, err := orm.Orm(new(InvalidSortedMenu), rows, orm.NewMapperOption())
+		assert.True(t, errors.Is(err, orm.ErrInvalidOrderTag))
+	})
+}
@@ -0,0 +1,90 @@
+package orm
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+PaginateRootQuery wrap query (which select root pk by GetSelector) to paginate distinct root model
+instead of joined rows, root is ranked by dense_rank() over orderBy + root pk.
+total_row is count of distinct root and root_rank is rank of root in the page,
+rows are ordered by root_rank then orderBy. use with Orm and paginator.SetTotalFromMapper(mapper).
+orderBy is selected column of root with optional direction, example "orders.created_at desc",
+column of joined relation is rejected because it is not one value per root.
+page after the last page has no row, total_row is 0.
+soft deleted root is filtered before rank, relation of query use SoftDeleteJoinCondition
+*/
+func PaginateRootQuery(model interface{}, query string, page int, perPage int, orderBy ...string) (string, error) {
+	return PaginateRootQueryWithOption(NewMapperOption(), model, query, page, perPage, orderBy...)
+}
+
+// PaginateRootQueryWithOption same as PaginateRootQuery with soft delete scope of MapperOption (SetWithDeleted, SetOnlyDeleted)
+func PaginateRootQueryWithOption(options MapperOption, model interface{}, query string, page int, perPage int, orderBy ...string) (string, error) {
+	if page < 1 || perPage < 1 {
+		return "", ErrInvalidPaginate
+	}
+	meta, err := getModelMeta(model)
+	if err != nil {
+		return "", err
+	}
+	orders, err := parseOrderTag(strings.Join(orderBy, fieldSeperate))
+	if err != nil {
+		return "", err
+	}
+
+	var pkNames = meta.getPKFields(options)
+	var rankOrders = make([]string, 0, len(orders)+len(pkNames))
+	var rowOrders = make([]string, 0, len(orders)+1)
+	rowOrders = append(rowOrders, PAGINATE_RANK_COLUMN_NAME)
+	for _, order := range orders {
+		/* rank of root by child column overcount root */
+		name, ok := strings.CutPrefix(order.name, meta.table+".")
+		if _, found := meta.columnMap[name]; !ok || !found {
+			return "", fmt.Errorf("%w: %s is not column of root %s", ErrInvalidOrderTag, order.name, meta.table)
+		}
+		/* column name from GetSelector is "tablename.column" as one identifier */
+		column := `."` + strings.ReplaceAll(order.name, `"`, `""`) + `"`
+		if order.desc {
+			column += " DESC"
+		}
+		rankOrders = append(rankOrders, "paginate_query"+column)
+		rowOrders = append(rowOrders, "paginate_root"+column)
+	}
+	pkFields, err := meta.getFields(pkNames)
+	if err != nil {
+		return "", err
+	}
+	for _, field := range pkFields {
+		if field.column == "" {
+			return "", &MappingError{Model: meta.modelType.String(), Field: field.name, Row: -1, Err: ErrPrimaryKeyNotFound}
+		}
+		rankOrders = append(rankOrders, fmt.Sprintf(`paginate_query."%s.%s"`, meta.table, field.column))
+	}
+
+	/* soft delete column from GetSelector is "tablename.column" */
+	var where string
+	if meta.softDelete != nil {
+		column := fmt.Sprintf(`paginate_query."%s.%s"`, meta.table, meta.softDelete.column)
+		if condition := softDeleteColumnCondition(meta, column, options.softDeleteScope); condition != "" {
+			where = " WHERE " + condition
+		}
+	}
+
+	offset := (page - 1) * perPage
+	return fmt.Sprintf(
+		`SELECT * FROM (`+
+			`SELECT paginate_rank.*, max(paginate_rank.%[1]s) OVER () AS %[2]s FROM (`+
+			`SELECT paginate_query.*, dense_rank() OVER (ORDER BY %[3]s) AS %[1]s FROM (%[4]s) paginate_query%[8]s`+
+			`) paginate_rank`+
+			`) paginate_root WHERE %[1]s > %[5]d AND %[1]s <= %[6]d ORDER BY %[7]s`,
+		PAGINATE_RANK_COLUMN_NAME,
+		PAGINATE_COLUMN_NAME,
+		strings.Join(rankOrders, ", "),
+		query,
+		offset,
+		offset+perPage,
+		strings.Join(rowOrders, ", "),
+		where,
+	), nil
+}
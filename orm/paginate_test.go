@@ -0,0 +1,125 @@
+package orm_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/Pheethy/psql/helper"
+	"github.com/Pheethy/psql/orm"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v2"
+)
+
+func TestPaginateRootQuery(t *testing.T) {
+	t.Run("success_rank_by_order_and_pk", func(t *testing.T) {
+		query, err := orm.PaginateRootQuery(new(Menu), `SELECT * FROM menus`, 2, 10, "menus.name desc")
+		assert.NoError(t, err)
+		assert.Equal(t,
+			`SELECT * FROM (SELECT paginate_rank.*, max(paginate_rank.root_rank) OVER () AS total_row FROM (`+
+				`SELECT paginate_query.*, dense_rank() OVER (ORDER BY paginate_query."menus.name" DESC, paginate_query."menus.id") AS root_rank `+
+				`FROM (SELECT * FROM menus) paginate_query) paginate_rank) paginate_root `+
+				`WHERE root_rank > 10 AND root_rank <= 20 ORDER BY root_rank, paginate_root."menus.name" DESC`,
+			query,
+		)
+	})
+
+	t.Run("error_invalid_page", func(t *testing.T) {
+		_, err := orm.PaginateRootQuery(new(Menu), `SELECT * FROM menus`, 0, 10)
+		assert.ErrorIs(t, err, orm.ErrInvalidPaginate)
+
+		_, err = orm.PaginateRootQuery(new(Menu), `SELECT * FROM menus`, 1, 10, "menus.name upward")
+		assert.ErrorIs(t, err, orm.ErrInvalidOrderTag)
+	})
+
+	t.Run("error_order_by_relation_column", func(t *testing.T) {
+		_, err := orm.PaginateRootQuery(new(Menu), `SELECT * FROM menus LEFT JOIN menu_items ON menus.id = menu_items.menu_id`, 1, 10, "menu_items.id")
+		assert.ErrorIs(t, err, orm.ErrInvalidOrderTag)
+
+		_, err = orm.PaginateRootQuery(new(Menu), `SELECT * FROM menus`, 1, 10, "menus.price")
+		assert.ErrorIs(t, err, orm.ErrInvalidOrderTag)
+	})
+
+	t.Run("success_rank_by_override_pk", func(t *testing.T) {
+		options := orm.NewMapperOption().SetOverridePKField(orm.NewMapperOptionPKField(Menu{}, []string{"Name"}))
+		query, err := orm.PaginateRootQueryWithOption(options, new(Menu), `SELECT * FROM menus`, 1, 10)
+		assert.NoError(t, err)
+		assert.Contains(t, query, `dense_rank() OVER (ORDER BY paginate_query."menus.name") AS root_rank`)
+	})
+
+	t.Run("success_total_is_distinct_root", func(t *testing.T) {
+		db, dbmock := newDB(t)
+
+		/* page 1 of 2 menus per page, 3 menus in total, 2 menus joined into 4 rows */
+		dbmock.ExpectQuery(regexp.QuoteMeta(`ORDER BY root_rank`)).WillReturnRows(
+			sqlmock.NewRows([]string{"menus.id", "menus.name", "menu_items.id", "menu_items.menu_id", "root_rank", "total_row"}).
+				AddRow(1, "Breakfast", 100, 1, 1, 3).
+				AddRow(1, "Breakfast", 101, 1, 1, 3).
+				AddRow(2, "Lunch", 102, 2, 2, 3).
+				AddRow(2, "Lunch", 103, 2, 2, 3),
+		)
+		query, err := orm.PaginateRootQuery(new(Menu), `SELECT * FROM menus LEFT JOIN menu_items ON menus.id = menu_items.menu_id`, 1, 2)
+		assert.NoError(t, err)
+		rows, err := db.Queryx(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		mapper, err := orm.Orm(new(Menu), rows, orm.NewMapperOption().SetStrictWarning())
+		assert.NoError(t, err)
+		assert.Equal(t, 4, mapper.GetRowCount())
+		assert.Equal(t, 2, mapper.GetRootCount())
+
+		paginator := helper.NewPaginatorWithParams(1, 2)
+		paginator.SetTotalFromMapper(mapper)
+		assert.Equal(t, 3, paginator.TotalEntrySizes)
+		assert.Equal(t, 2, paginator.TotalPages)
+		assert.True(t, paginator.HasNext())
+		assert.Equal(t, 0, paginator.Offset())
+
+		menus := mapper.GetData().([]*Menu)
+		assert.Len(t, menus, 2)
+		assert.Len(t, menus[1].Items, 2)
+		for _, warning := range mapper.GetWarnings() {
+			assert.NotErrorIs(t, warning, orm.ErrUnmappedColumn)
+		}
+	})
+	t.Run("success_numeric_total_row", func(t *testing.T) {
+		db, dbmock := newDB(t)
+
+		/* count(*)::numeric is []byte from driver */
+		dbmock.ExpectQuery(regexp.QuoteMeta(`ORDER BY root_rank`)).WillReturnRows(
+			sqlmock.NewRows([]string{"menus.id", "menus.name", "root_rank", "total_row"}).
+				AddRow(1, "Breakfast", 1, []byte("5")),
+		)
+		rows, err := db.Queryx(`SELECT * FROM menus ORDER BY root_rank`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		mapper, err := orm.Orm(new(Menu), rows, orm.NewMapperOption())
+		assert.NoError(t, err)
+		assert.Equal(t, 5, mapper.GetPaginateTotal())
+	})
+
+	t.Run("error_invalid_total_row", func(t *testing.T) {
+		db, dbmock := newDB(t)
+
+		dbmock.ExpectQuery(regexp.QuoteMeta(`ORDER BY root_rank`)).WillReturnRows(
+			sqlmock.NewRows([]string{"menus.id", "menus.name", "root_rank", "total_row"}).
+				AddRow(1, "Breakfast", 1, []byte("five")),
+		)
+		rows, err := db.Queryx(`SELECT * FROM menus ORDER BY root_rank`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		_, err = orm.Orm(new(Menu), rows, orm.NewMapperOption())
+		var mappingErr *orm.MappingError
+		if assert.ErrorAs(t, err, &mappingErr) {
+			assert.Equal(t, orm.PAGINATE_COLUMN_NAME, mappingErr.Column)
+		}
+	})
+}
@@ -0,0 +1,220 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	pg "github.com/lib/pq"
+)
+
+type preloadRelation struct {
+	field  string
+	nested []string // preload of relation model, "Toppings.Items" -> Items
+}
+
+// groupPreloads group "Toppings", "Toppings.Items" by relation field of root, keep first seen order
+func groupPreloads(preloads []string) []preloadRelation {
+	var relations = make([]preloadRelation, 0, len(preloads))
+	var indexes = make(map[string]int, len(preloads))
+	for _, preload := range preloads {
+		parts := strings.SplitN(strings.TrimSpace(preload), ".", 2)
+		if parts[0] == "" {
+			continue
+		}
+		index, ok := indexes[parts[0]]
+		if !ok {
+			index = len(relations)
+			indexes[parts[0]] = index
+			relations = append(relations, preloadRelation{field: parts[0]})
+		}
+		if len(parts) == 2 && parts[1] != "" {
+			relations[index].nested = append(relations[index].nested, parts[1])
+		}
+	}
+	return relations
+}
+
+/*
+preload load relation of SetPreload after root rows is mapped,
+one query per relation (`WHERE fk = ANY($1)`, composite fk use `WHERE (fk1,fk2) IN (...)`)
+and bind into root models by fk value like join
+*/
+func preload(ctx context.Context, mapper *Mapper, options MapperOption) error {
+	mainModel := modelStructs(mapper.modelStructs).GetMainModel()
+	if mainModel.modelSlice.Len() == 0 {
+		return nil
+	}
+	if options.preloader == nil {
+		return ErrMustNotNil
+	}
+	for _, relation := range groupPreloads(options.preloads) {
+		if err := preloadRelationField(ctx, mainModel.meta, mainModel.modelSlice, relation, options); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func preloadRelationField(ctx context.Context, meta *modelMeta, parents reflect.Value, relation preloadRelation, options MapperOption) error {
+	var newError = func(err error) error {
+		return &MappingError{Model: meta.modelType.String(), Field: relation.field, Row: -1, Err: err}
+	}
+	fk, ok := meta.fks[relation.field]
+	if !ok {
+		return newError(fmt.Errorf("%w: preload relation must have fk tag", ErrFieldNotFound))
+	}
+	relationField := meta.fieldByName[relation.field]
+	childType := relationField.fieldType
+	if childType.Kind() == reflect.Slice {
+		childType = childType.Elem()
+	}
+	childMeta, err := getModelMeta(childType)
+	if err != nil {
+		return newError(err)
+	}
+	parentFields, err := meta.getFields(fk.fkField1)
+	if err != nil {
+		return err
+	}
+	childFields, err := childMeta.getFields(fk.fkField2)
+	if err != nil {
+		return err
+	}
+	for _, field := range parentFields {
+		if _, err := field.getRegistry(options); err != nil {
+			return newError(err)
+		}
+	}
+
+	/* distinct fk value of parents, null fk never join */
+	var seen = make(map[string]bool, parents.Len())
+	var tuples = make([][]interface{}, 0, parents.Len())
+	for index := 0; index < parents.Len(); index++ {
+		key, ok := joinKey(parents.Index(index), parentFields, parentFields, options)
+		if !ok {
+			return newError(ErrInvalidRegistry)
+		}
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		var tuple = make([]interface{}, 0, len(parentFields))
+		for _, field := range parentFields {
+			val, err := getColumnValue(parents.Index(index), writeColumn{name: field.column, field: field.name, meta: field}, options)
+			if err != nil {
+				return newError(err)
+			}
+			tuple = append(tuple, val)
+		}
+		tuples = append(tuples, tuple)
+	}
+
+	var children = getEmptySlice(childType, 0)
+	if len(tuples) > 0 {
+		var childOption = options
+		childOption.preloads = relation.nested
+		childOption.copyIntoIteration = false
+		childOption.skipAfterFind = true // AfterFind of relation is called with root
+		childOption.trackChanges = false // relation is tracked with root
+		childOption.softDeleteScope = relationScope(childOption.softDeleteScope)
+		if children, err = queryPreload(ctx, childType, childMeta, childFields, tuples, childOption); err != nil {
+			return newError(err)
+		}
+	}
+
+	var childrenByKey = make(map[string][]reflect.Value, children.Len())
+	for index := 0; index < children.Len(); index++ {
+		key, ok := joinKey(children.Index(index), parentFields, childFields, options)
+		if ok && key != "" {
+			childrenByKey[key] = append(childrenByKey[key], children.Index(index))
+		}
+	}
+	for index := 0; index < parents.Len(); index++ {
+		parent := parents.Index(index)
+		key, _ := joinKey(parent, parentFields, parentFields, options)
+		target := parent.Elem().FieldByIndex(relationField.index)
+		if target.Kind() == reflect.Ptr {
+			/* object */
+			if matches := childrenByKey[key]; key != "" && len(matches) > 0 {
+				target.Set(copy(matches[0]))
+			}
+			continue
+		}
+		/* slice */
+		var slice = reflect.MakeSlice(target.Type(), 0, len(childrenByKey[key]))
+		if key != "" {
+			for _, child := range childrenByKey[key] {
+				slice = reflect.Append(slice, copy(child))
+			}
+		}
+		if err := sortModels(slice, childMeta, relationField.orderBy); err != nil {
+			return err
+		}
+		target.Set(slice)
+	}
+	return nil
+}
+
+func queryPreload(ctx context.Context, childType reflect.Type, childMeta *modelMeta, childFields []*fieldMeta, tuples [][]interface{}, options MapperOption) (reflect.Value, error) {
+	var children = getEmptySlice(childType, 0)
+	var columns = make([]string, 0, len(childFields))
+	for _, field := range childFields {
+		if field.column == "" {
+			return children, fmt.Errorf("%w: fk_field2 %s has no db tag", ErrColumnNotFound, field.name)
+		}
+		columns = append(columns, quoteIdentifier(childMeta.table)+"."+quoteIdentifier(field.column))
+	}
+
+	chunkSize := len(tuples)
+	if len(columns) > 1 {
+		chunkSize = maxBindParameters / len(columns)
+	}
+	for start := 0; start < len(tuples); start += chunkSize {
+		end := start + chunkSize
+		if end > len(tuples) {
+			end = len(tuples)
+		}
+		query, args := preloadStatement(childMeta, columns, tuples[start:end], options.softDeleteScope)
+		rows, err := options.preloader.QueryxContext(ctx, query, args...)
+		if err != nil {
+			return children, err
+		}
+		mapper, err := orm(ctx, reflect.New(childType.Elem()).Interface(), rows, options)
+		rows.Close()
+		if err != nil {
+			return children, err
+		}
+		children = reflect.AppendSlice(children, reflect.ValueOf(mapper.GetData()))
+	}
+	return children, nil
+}
+
+func preloadStatement(childMeta *modelMeta, columns []string, tuples [][]interface{}, scope SoftDeleteScopes) (string, []interface{}) {
+	var condition string
+	var args = make([]interface{}, 0, len(tuples)*len(columns))
+	if len(columns) == 1 {
+		var values = make([]interface{}, 0, len(tuples))
+		for _, tuple := range tuples {
+			values = append(values, tuple[0])
+		}
+		args = append(args, pg.Array(values))
+		condition = fmt.Sprintf(`%s = ANY($1)`, columns[0])
+	} else {
+		var placeholders = make([]string, 0, len(tuples))
+		for _, tuple := range tuples {
+			var tuplePlaceholders = make([]string, 0, len(tuple))
+			for _, val := range tuple {
+				args = append(args, val)
+				tuplePlaceholders = append(tuplePlaceholders, fmt.Sprintf("$%d", len(args)))
+			}
+			placeholders = append(placeholders, "("+strings.Join(tuplePlaceholders, ",")+")")
+		}
+		condition = fmt.Sprintf(`(%s) IN (%s)`, strings.Join(columns, ","), strings.Join(placeholders, ","))
+	}
+	if softDelete := softDeleteCondition(childMeta, childMeta.table, scope); softDelete != "" {
+		condition += " AND " + softDelete
+	}
+	return fmt.Sprintf(`SELECT %s FROM %s WHERE %s`, GetSelector(childMeta.modelType), quoteIdentifier(childMeta.table), condition), args
+}
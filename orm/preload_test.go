@@ -0,0 +1,152 @@
+package orm_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/Pheethy/psql/orm"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v2"
+)
+
+type Kitchen struct {
+	TableName struct{}       `json:"-" db:"kitchens" pk:"ID"`
+	ID        int            `json:"id" db:"id"`
+	Name      string         `json:"name" db:"name"`
+	Menus     []*KitchenMenu `json:"menus" db:"-" fk:"fk_field1:ID,fk_field2:KitchenID"`
+}
+
+type KitchenMenu struct {
+	TableName struct{}    `json:"-" db:"menus" pk:"ID"`
+	ID        int         `json:"id" db:"id"`
+	KitchenID int         `json:"kitchen_id" db:"kitchen_id"`
+	Items     []*MenuItem `json:"items" db:"-" fk:"fk_field1:ID,fk_field2:MenuID" order:"Price desc"`
+}
+
+func TestPreload(t *testing.T) {
+	t.Run("success_preload_slice_without_join", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		orderID1, _ := uuid.NewV4()
+		orderID2, _ := uuid.NewV4()
+		chefID, _ := uuid.NewV4()
+
+		dbmock.ExpectQuery(`SELECT (.+) orders`).WillReturnRows(
+			sqlmock.NewRows([]string{"orders.id", "orders.name", "orders.chef_id", "chefs.id", "chefs.name", "total_row"}).
+				AddRow(orderID1.String(), "Cake", chefID.String(), chefID.String(), "Gordon", 3).
+				AddRow(orderID2.String(), "Raised", chefID.String(), chefID.String(), "Gordon", 3),
+		)
+		dbmock.ExpectQuery(regexp.QuoteMeta(
+			`SELECT toppings.id "toppings.id",toppings.type "toppings.type",toppings.order_id "toppings.order_id" ` +
+				`FROM "toppings" WHERE "toppings"."order_id" = ANY($1)`,
+		)).
+			WithArgs(`{"` + orderID1.String() + `","` + orderID2.String() + `"}`).
+			WillReturnRows(sqlmock.NewRows([]string{"toppings.id", "toppings.type", "toppings.order_id"}).
+				AddRow(5001, "None", orderID1.String()).
+				AddRow(5002, "Glazed", orderID1.String()).
+				AddRow(5003, "Sugar", orderID2.String()),
+			)
+
+		rows, err := db.Queryx(`SELECT * FROM orders`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		mapper, err := orm.Orm(new(Order), rows, orm.NewMapperOption().SetPreload(db, "Toppings"))
+		assert.NoError(t, err)
+		assert.Equal(t, 2, mapper.GetRowCount())
+		assert.Equal(t, 3, mapper.GetPaginateTotal())
+
+		orders := mapper.GetData().([]*Order)
+		assert.Len(t, orders, 2)
+		assert.Equal(t, "Gordon", orders[0].Chef.Name)
+		assert.Len(t, orders[0].Toppings, 2)
+		assert.Equal(t, 5001, orders[0].Toppings[0].ID)
+		assert.Equal(t, 5002, orders[0].Toppings[1].ID)
+		assert.Len(t, orders[1].Toppings, 1)
+		assert.Equal(t, "Sugar", orders[1].Toppings[0].Type)
+		assert.Empty(t, orders[0].Batters)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("success_preload_nested", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		dbmock.ExpectQuery(`SELECT (.+) kitchens`).WillReturnRows(
+			sqlmock.NewRows([]string{"kitchens.id", "kitchens.name"}).AddRow(1, "Main").AddRow(2, "Bar"),
+		)
+		dbmock.ExpectQuery(regexp.QuoteMeta(`FROM "menus" WHERE "menus"."kitchen_id" = ANY($1)`)).
+			WithArgs(`{1,2}`).
+			WillReturnRows(sqlmock.NewRows([]string{"menus.id", "menus.kitchen_id"}).AddRow(10, 1).AddRow(11, 1))
+		dbmock.ExpectQuery(regexp.QuoteMeta(`FROM "menu_items" WHERE "menu_items"."menu_id" = ANY($1)`)).
+			WithArgs(`{10,11}`).
+			WillReturnRows(sqlmock.NewRows([]string{"menu_items.id", "menu_items.price", "menu_items.menu_id"}).
+				AddRow(100, 1.5, 10).
+				AddRow(101, 3.0, 10).
+				AddRow(102, 2.0, 11),
+			)
+
+		rows, err := db.Queryx(`SELECT * FROM kitchens`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		mapper, err := orm.Orm(new(Kitchen), rows, orm.NewMapperOption().SetPreload(db, "Menus.Items"))
+		assert.NoError(t, err)
+
+		kitchens := mapper.GetData().([]*Kitchen)
+		assert.Len(t, kitchens, 2)
+		assert.Len(t, kitchens[0].Menus, 2)
+		assert.Equal(t, []int{101, 100}, []int{kitchens[0].Menus[0].Items[0].ID, kitchens[0].Menus[0].Items[1].ID})
+		assert.Len(t, kitchens[0].Menus[1].Items, 1)
+		assert.Empty(t, kitchens[1].Menus)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("success_preload_composite_fk", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		dbmock.ExpectQuery(`SELECT (.+) sales_orders`).WillReturnRows(
+			sqlmock.NewRows([]string{"sales_orders.no", "sales_orders.branch"}).AddRow(1, 0).AddRow(1, 1),
+		)
+		dbmock.ExpectQuery(regexp.QuoteMeta(
+			`FROM "sales_order_lines" WHERE ("sales_order_lines"."order_no","sales_order_lines"."branch") IN (($1,$2),($3,$4))`,
+		)).
+			WithArgs(1, 0, 1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"sales_order_lines.order_no", "sales_order_lines.branch", "sales_order_lines.line_no"}).
+				AddRow(1, 1, 0).
+				AddRow(1, 0, 0).
+				AddRow(1, 0, 1),
+			)
+
+		rows, err := db.Queryx(`SELECT * FROM sales_orders`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		mapper, err := orm.Orm(new(SalesOrder), rows, orm.NewMapperOption().SetPreload(db, "Lines"))
+		assert.NoError(t, err)
+
+		orders := mapper.GetData().([]*SalesOrder)
+		assert.Len(t, orders[0].Lines, 2)
+		assert.Len(t, orders[1].Lines, 1)
+		assert.Equal(t, 1, orders[1].Lines[0].Branch)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("error_preload_unknown_relation", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		dbmock.ExpectQuery(`SELECT (.+) kitchens`).WillReturnRows(
+			sqlmock.NewRows([]string{"kitchens.id", "kitchens.name"}).AddRow(1, "Main"),
+		)
+		rows, err := db.Queryx(`SELECT * FROM kitchens`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		_, err = orm.Orm(new(Kitchen), rows, orm.NewMapperOption().SetPreload(db, "Name"))
+		assert.ErrorIs(t, err, orm.ErrFieldNotFound)
+	})
+}
@@ -2,13 +2,11 @@ package orm
 
 import (
 	"database/sql"
-	"fmt"
-	"log"
+	"database/sql/driver"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
-
-	"github.com/fatih/structs"
 )
 
 var TAGNAME = "db"
@@ -16,7 +14,11 @@ var TABLE_FIELD_NAME = "TableName"
 var TAG_PK = "pk"
 var TAG_FK = "fk"
 var TAG_TYPE = "type"
+var TAG_ORDER = "order"
+var TAG_PREFIX = "prefix"
+var TAG_SOFT_DELETE = "softdelete"
 var PAGINATE_COLUMN_NAME = "total_row"
+var PAGINATE_RANK_COLUMN_NAME = "root_rank"
 var fieldSeperate = ","
 var fieldFKSeperate = "+"
 var fieldJoinKeyMap = "+"
@@ -50,158 +52,116 @@ func copy(src reflect.Value) reflect.Value {
 	return destPtr
 }
 
-func getTableName(faith *structs.Struct) string {
-	var tablename string
-
-	if f, ok := faith.FieldOk(TABLE_FIELD_NAME); ok {
-		tablename = f.Tag(TAGNAME)
-	}
-
-	return tablename
-}
-
-func getTagValue(faith *structs.Struct, field string, tag string) string {
-	var tagVal string
-
-	if f, ok := faith.FieldOk(field); ok {
-		tagVal = strings.TrimSpace(f.Tag(tag))
-	}
-
-	return tagVal
-}
-
-func getFieldValue(faith *structs.Struct, field string) (interface{}, error) {
-	f, ok := faith.FieldOk(field)
-	if !ok {
-		log.Println("field ", field, "not found")
-		return reflect.New(nil), ErrFieldNotFound
+func fillValue(ptr interface{}, columns []*sql.ColumnType, values []interface{}, row int, options MapperOption) error {
+	meta, err := getModelMeta(ptr)
+	if err != nil {
+		return err
 	}
 
-	return f.Value(), nil
+	return fillByPlan(ptr, meta.planColumns(columns, ""), columns, values, row, options)
 }
 
-func getFieldValues(faith *structs.Struct, fields []string) ([]interface{}, error) {
-	var vals = make([]interface{}, 0)
+/*
+getIds return composite key of fields, every component is quoted
+so "a+b","c" and "a","b+c" is not the same key.
+return "" when some component is null (key is absent), zero value is a valid component
+*/
+func getIds(elem reflect.Value, fields []*fieldMeta, options MapperOption) (string, error) {
+	var ids = make([]string, 0, len(fields))
 	for _, field := range fields {
-		v, err := getFieldValue(faith, field)
+		registry, err := field.getRegistry(options)
+		if err == nil && registry == nil {
+			err = ErrRegistryNotFound
+		}
 		if err != nil {
-			return nil, err
+			return "", &MappingError{Model: reflect.Indirect(elem).Type().String(), Field: field.name, Column: field.column, Row: -1, Err: err}
 		}
-		vals = append(vals, v)
-	}
-	return vals, nil
-}
 
-func getFieldMetaData(faith *structs.Struct, option MapperOption) (pkFields []string, fkFields []string) {
-	pkValField := getTagValue(faith, TABLE_FIELD_NAME, TAG_PK)
-	pkFields = strings.Split(pkValField, fieldSeperate)
-	fkFields = make([]string, 0)
-
-	if len(option.pkFields) > 0 {
-		for _, pkField := range option.pkFields {
-			if pkField.faith.Name() == faith.Name() {
-				pkFields = pkField.fieldName
-				break
-			}
+		val := field.value(elem)
+		if isNullValue(registry, val) {
+			return "", nil
 		}
+		ids = append(ids, registry.RegisterPkId(val))
 	}
+	return joinIds(ids), nil
+}
 
-	for _, field := range faith.Fields() {
-		if field.IsEmbedded() {
-			continue
-		}
-		if field.Tag(TAG_FK) != "" {
-			fkFields = append(fkFields, field.Name())
-		}
+func joinIds(ids []string) string {
+	var quoted = make([]string, len(ids))
+	for index, id := range ids {
+		quoted[index] = strconv.Quote(id)
 	}
-
-	return pkFields, fkFields
+	return strings.Join(quoted, fieldJoinKeyMap)
 }
 
-func getStructFields(faith *structs.Struct) *sync.Map {
-	var ptrColumnMap = new(sync.Map)
-	fields := faith.Fields()
-	for _, f := range fields {
-		tagCol := f.Tag(TAGNAME)
-		if tagCol != "" && tagCol != "-" {
-			ptrColumnMap.Store(tagCol, f)
-		}
+/*
+isNullValue is null rule of key component, RegistryNullable decide first
+then nil, nil pointer and driver.Valuer which Value() is nil (sql.Null*, helper.ZeroUUID)
+*/
+func isNullValue(registry Registry, val interface{}) bool {
+	if nullable, ok := registry.(RegistryNullable); ok {
+		return nullable.IsNull(val)
 	}
+	if isNil(val) {
+		return true
+	}
+	if valuer, ok := val.(driver.Valuer); ok {
+		driverVal, err := valuer.Value()
+		return err == nil && driverVal == nil
+	}
+	return false
+}
 
-	return ptrColumnMap
+/*
+nullFields keep field which scanned driver value is NULL by model pointer,
+NULL is bound as zero value ("" of string) so key of model must be checked here before registry value
+*/
+type nullFields struct {
+	models sync.Map // model pointer -> map[*fieldMeta]bool
 }
 
-func setFieldFromType(field *structs.Field, data interface{}) error {
-	var tag = field.Tag(TAG_TYPE)
-	if tag != "" && tag != "-" {
-		registry, ok := GlobalRegistry[tag]
-		if !ok {
-			return fmt.Errorf("error: %s %s", tag, ErrRegistryNotFound.Error())
+// store fields of plan which scanned value is nil
+func (n *nullFields) store(elem reflect.Value, plan []*fieldMeta, values []interface{}) {
+	if n == nil {
+		return
+	}
+	var fields map[*fieldMeta]bool
+	for index, field := range plan {
+		if field == nil || values[index] != nil {
+			continue
 		}
-		if err := registry.Bind(field, data); err != nil {
-			return err
+		if fields == nil {
+			fields = make(map[*fieldMeta]bool)
 		}
+		fields[field] = true
 	}
-
-	return nil
-}
-
-func fillValue(ptr interface{}, columns []*sql.ColumnType, values []interface{}) error {
-	faith := structs.New(ptr)
-	schTableName := getTableName(faith)
-
-	ptrColumnMap := getStructFields(faith)
-
-	if len(values) > 0 {
-		for index, col := range columns {
-			orderCol := strings.ReplaceAll(col.Name(), schTableName+".", "")
-			if field, ok := ptrColumnMap.Load(orderCol); ok {
-				if err := setFieldFromType(field.(*structs.Field), values[index]); err != nil {
-					return err
-				}
-			}
-		}
+	if fields != nil {
+		n.models.Store(elem.Pointer(), fields)
 	}
-
-	return nil
 }
 
-func transfromIdString(faith *structs.Struct, field string, tag string, val interface{}) (string, error) {
-	typeVal := getTagValue(faith, field, tag)
-	registry, ok := GlobalRegistry[typeVal]
+// isNull return true when some field of model is scanned from NULL, false for nil nullFields
+func (n *nullFields) isNull(elem reflect.Value, fields []*fieldMeta) bool {
+	if n == nil {
+		return false
+	}
+	val, ok := n.models.Load(elem.Pointer())
 	if !ok {
-		return "", ErrRegistryNotFound
+		return false
 	}
-
-	return registry.RegisterPkId(val), nil
-}
-
-func getIds(faith *structs.Struct, fields []string) (string, error) {
-	var ids = []string{}
 	for _, field := range fields {
-		val, err := getFieldValue(faith, field)
-		if err != nil {
-			return "", err
+		if val.(map[*fieldMeta]bool)[field] {
+			return true
 		}
-
-		id, err := transfromIdString(faith, field, TAG_TYPE, val)
-		if err != nil {
-			return "", err
-		}
-		if id == "" {
-			return "", nil
-		}
-		ids = append(ids, id)
 	}
-	return strings.Join(ids, "+"), nil
+	return false
 }
 
 /*
 Equal Value if a same type
 */
-func equal(tagTypeVal string, x interface{}, y interface{}) bool {
-	if !isNil(x) && !isNil(y) {
-		registry := GlobalRegistry[tagTypeVal]
+func equal(registry Registry, x interface{}, y interface{}) bool {
+	if registry != nil && !isNullValue(registry, x) && !isNullValue(registry, y) {
 		return registry.Equal(x, y)
 	}
 	return false
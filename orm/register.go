@@ -0,0 +1,135 @@
+package orm
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/fatih/structs"
+	"github.com/spf13/cast"
+)
+
+var registryMutex sync.RWMutex
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+/*
+RegisterType add registry into globalRegistry with name use on `type:"name"` tag,
+safe for concurrent use. return ErrRegistryDuplicate when name already exists
+*/
+func RegisterType(name string, registry Registry) error {
+	if err := validateRegistry(name, registry); err != nil {
+		return err
+	}
+
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	if _, ok := globalRegistry[name]; ok {
+		return fmt.Errorf("%w: %s", ErrRegistryDuplicate, name)
+	}
+	globalRegistry[name] = registry
+	return nil
+}
+
+// MustRegisterType is like RegisterType but panic, for use in init()
+func MustRegisterType(name string, registry Registry) {
+	if err := RegisterType(name, registry); err != nil {
+		panic(err)
+	}
+}
+
+func validateRegistry(name string, registry Registry) error {
+	if name == "" || name == "-" || strings.ContainsAny(name, " \t\n,") {
+		return fmt.Errorf("%w: invalid name %q", ErrInvalidRegistry, name)
+	}
+	if isNil(registry) {
+		return fmt.Errorf("%w: %s must not be nil", ErrInvalidRegistry, name)
+	}
+	if scanner, ok := registry.(scannerRegistry); ok {
+		if scanner.modelType == nil || !reflect.PtrTo(scanner.modelType).Implements(scannerType) {
+			return fmt.Errorf("%w: %s model must implement sql.Scanner", ErrInvalidRegistry, name)
+		}
+	}
+	return nil
+}
+
+// LookupType registry of name from RegisterType and built-in type, safe for concurrent use
+func LookupType(name string) (Registry, bool) {
+	return lookupRegistry(name)
+}
+
+func lookupRegistry(name string) (Registry, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	registry, ok := globalRegistry[name]
+	return registry, ok
+}
+
+/*
+NewScannerRegistry adapt type which pointer implement sql.Scanner
+(and optional driver.Valuer) into Registry.
+field can be T or *T, example
+
+	orm.RegisterType("money", orm.NewScannerRegistry("money", Money{}))
+*/
+func NewScannerRegistry(name string, model interface{}) Registry {
+	modelType := reflect.TypeOf(model)
+	if modelType != nil && modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+	return scannerRegistry{name: name, modelType: modelType}
+}
+
+type scannerRegistry struct {
+	name      string
+	modelType reflect.Type
+}
+
+func (elem scannerRegistry) TypeName() string {
+	return elem.name
+}
+
+func (elem scannerRegistry) RegisterPkId(val interface{}) string {
+	val, err := getDriverValue(val)
+	if err != nil || val == nil {
+		return ""
+	}
+	return cast.ToString(val)
+}
+
+func (elem scannerRegistry) Bind(field *structs.Field, val interface{}) error {
+	if val == nil {
+		return nil
+	}
+	fieldType := reflect.TypeOf(field.Value())
+	elemType := fieldType
+	if fieldType.Kind() == reflect.Ptr {
+		elemType = fieldType.Elem()
+	}
+	if elem.modelType != nil && elemType != elem.modelType {
+		return fmt.Errorf("%w: %s can not bind into %s", ErrInvalidRegistry, elem.name, fieldType)
+	}
+	if !reflect.PtrTo(elemType).Implements(scannerType) {
+		return fmt.Errorf("%w: %s is not implement sql.Scanner", ErrInvalidRegistry, fieldType)
+	}
+
+	ptr := reflect.New(elemType)
+	if err := ptr.Interface().(sql.Scanner).Scan(val); err != nil {
+		return err
+	}
+	if fieldType.Kind() == reflect.Ptr {
+		return field.Set(ptr.Interface())
+	}
+	return field.Set(ptr.Elem().Interface())
+}
+
+func (elem scannerRegistry) Equal(x interface{}, y interface{}) bool {
+	xVal, xErr := getDriverValue(x)
+	yVal, yErr := getDriverValue(y)
+	if xErr != nil || yErr != nil || xVal == nil || yVal == nil {
+		return false
+	}
+	return reflect.DeepEqual(xVal, yVal)
+}
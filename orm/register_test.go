@@ -0,0 +1,114 @@
+package orm_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	helperModel "github.com/Pheethy/psql/helper"
+	"github.com/Pheethy/psql/orm"
+	"github.com/Pheethy/sqlx"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v2"
+)
+
+type Invoice struct {
+	TableName struct{}             `json:"-" db:"invoices" pk:"ID"`
+	ID        int64                `json:"id" db:"id" type:"int64"`
+	RefID     helperModel.NullUUID `json:"ref_id" db:"ref_id" type:"nulluuid"`
+}
+
+func TestRegisterType(t *testing.T) {
+	t.Run("error_duplicate_name", func(t *testing.T) {
+		err := orm.RegisterType("string", orm.NewScannerRegistry("string", helperModel.NullUUID{}))
+		assert.ErrorIs(t, err, orm.ErrRegistryDuplicate)
+	})
+
+	t.Run("error_invalid_registry", func(t *testing.T) {
+		assert.ErrorIs(t, orm.RegisterType("", orm.NewScannerRegistry("", helperModel.NullUUID{})), orm.ErrInvalidRegistry)
+		assert.ErrorIs(t, orm.RegisterType("nil_registry", nil), orm.ErrInvalidRegistry)
+		assert.ErrorIs(t, orm.RegisterType("not_scanner", orm.NewScannerRegistry("not_scanner", struct{}{})), orm.ErrInvalidRegistry)
+	})
+
+	t.Run("error_invalid_mapper_registry", func(t *testing.T) {
+		db, _ := newDB(t)
+		option := orm.NewMapperOption().SetRegistry("not_scanner", orm.NewScannerRegistry("not_scanner", struct{}{}))
+
+		_, err := orm.Orm(new(Invoice), new(sqlx.Rows), option)
+		assert.ErrorIs(t, err, orm.ErrInvalidRegistry)
+
+		ctx := orm.WithMapperOption(context.Background(), orm.NewMapperOption().SetRegistry("", orm.NewScannerRegistry("", helperModel.NullUUID{})))
+		assert.ErrorIs(t, orm.Insert(ctx, db, &Invoice{}), orm.ErrInvalidRegistry)
+	})
+
+	t.Run("success_mapper_registry_with_scanner", func(t *testing.T) {
+		db, dbmock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		sqlxDB := sqlx.NewDb(db, "sqlmock")
+		defer db.Close()
+
+		refID, _ := uuid.NewV4()
+		dbmock.ExpectQuery(`SELECT (.+) invoices`).WillReturnRows(
+			sqlmock.NewRows([]string{"invoices.id", "invoices.ref_id"}).
+				AddRow(1, refID.String()).
+				AddRow(2, nil),
+		)
+		rows, err := sqlxDB.Queryx(`SELECT * FROM invoices`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		option := orm.NewMapperOption().SetRegistry("nulluuid", orm.NewScannerRegistry("nulluuid", helperModel.NullUUID{}))
+		mapper, err := orm.Orm(new(Invoice), rows, option)
+		assert.NoError(t, err)
+
+		invoices := mapper.GetData().([]*Invoice)
+		assert.Len(t, invoices, 2)
+		assert.True(t, invoices[0].RefID.Valid)
+		assert.Equal(t, refID.String(), invoices[0].RefID.UUID.String())
+		assert.False(t, invoices[1].RefID.Valid)
+	})
+
+	t.Run("success_write_with_mapper_registry", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		option := orm.NewMapperOption().SetRegistry("nulluuid", orm.NewScannerRegistry("nulluuid", helperModel.NullUUID{}))
+		refID, _ := uuid.NewV4()
+		invoice := &Invoice{RefID: helperModel.NullUUID{UUID: helperModel.ZeroUUID(refID), Valid: true}}
+
+		sql := `INSERT INTO "invoices" ("id","ref_id") VALUES (DEFAULT,$1) RETURNING "id","ref_id"`
+		for i := 0; i < 2; i++ {
+			dbmock.ExpectQuery(regexp.QuoteMeta(sql)).
+				WithArgs(refID.String()).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "ref_id"}).AddRow(1, refID.String()))
+		}
+
+		/* registry of mapper is not global */
+		err := orm.Insert(context.Background(), db, invoice)
+		assert.ErrorIs(t, err, orm.ErrRegistryNotFound)
+
+		invoice = &Invoice{RefID: helperModel.NullUUID{UUID: helperModel.ZeroUUID(refID), Valid: true}}
+		err = orm.Insert(orm.WithMapperOption(context.Background(), option), db, invoice)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), invoice.ID)
+		assert.Equal(t, refID, *invoice.RefID.UUID.ToUUID())
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("success_lookup_type", func(t *testing.T) {
+		registry, ok := orm.LookupType("string")
+		assert.True(t, ok)
+		assert.Equal(t, "string", registry.TypeName())
+		_, ok = orm.LookupType("nulluuid")
+		assert.False(t, ok)
+	})
+
+	t.Run("success_deprecated_global_registry", func(t *testing.T) {
+		registry, ok := orm.GlobalRegistry["string"]
+		assert.True(t, ok)
+		assert.Equal(t, "string", registry.TypeName())
+	})
+}
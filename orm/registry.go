@@ -1,7 +1,11 @@
 package orm
 
 import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,7 +23,37 @@ type Registry interface {
 	Equal(x interface{}, y interface{}) bool
 }
 
-var GlobalRegistry = map[string]Registry{
+/*
+RegistryValuer is optional for Registry which field type
+can not pass into database driver directly (json, array)
+used by Insert/Update/Upsert
+*/
+type RegistryValuer interface {
+	DriverValue(val interface{}) (driver.Value, error)
+}
+
+/*
+RegistryNullable is optional for Registry to tell which value is null,
+null component make composite key absent (row of LEFT JOIN without data).
+default null is nil, nil pointer and driver.Valuer which Value() is nil
+*/
+type RegistryNullable interface {
+	IsNull(val interface{}) bool
+}
+
+/*
+RegistryColumnType is optional for Registry to tell strict mode
+which database type name (sql.ColumnType DatabaseTypeName) can bind into it
+*/
+type RegistryColumnType interface {
+	AcceptColumnType(databaseTypeName string) bool
+}
+
+/*
+globalRegistry is default registry of every mapper,
+add with RegisterType and read with LookupType under registryMutex
+*/
+var globalRegistry = map[string]Registry{
 	(uid{}).TypeName():                         uid{},
 	(guid{}).TypeName():                        guid{},
 	(str("")).TypeName():                       str(""),
@@ -35,8 +69,26 @@ var GlobalRegistry = map[string]Registry{
 	(zeroFloat(zero.Float{})).TypeName():       zeroFloat(zero.Float{}),
 	(zeroBool(zero.Bool{})).TypeName():         zeroBool(zero.Bool{}),
 	(boolean(true)).TypeName():                 (boolean(true)),
+	(decimal(helper.Decimal{})).TypeName():     decimal(helper.Decimal{}),
+	(jsonType("json")).TypeName():              jsonType("json"),
+	(jsonType("jsonb")).TypeName():             jsonType("jsonb"),
+	stringArray.TypeName():                     stringArray,
+	intArray.TypeName():                        intArray,
+	int32Array.TypeName():                      int32Array,
+	int64Array.TypeName():                      int64Array,
+	float32Array.TypeName():                    float32Array,
+	float64Array.TypeName():                    float64Array,
+	boolArray.TypeName():                       boolArray,
+	uuidArray.TypeName():                       uuidArray,
 }
 
+/*
+GlobalRegistry is the same map as globalRegistry.
+
+Deprecated: write and read of the map is not safe for concurrent use, use RegisterType and LookupType
+*/
+var GlobalRegistry = globalRegistry
+
 /*
 ----------------------------------------
 |
@@ -192,9 +244,6 @@ func (elem str) Bind(field *structs.Field, val interface{}) error {
 }
 
 func (elem str) Equal(x interface{}, y interface{}) bool {
-	if x.(string) == "" || y.(string) == "" {
-		return false
-	}
 	return x.(string) == y.(string)
 }
 
@@ -224,9 +273,6 @@ func (elem integer) Bind(field *structs.Field, val interface{}) error {
 }
 
 func (elem integer) Equal(x interface{}, y interface{}) bool {
-	if cast.ToInt(cast.ToString(x)) == 0 || cast.ToInt(cast.ToString(y)) == 0 {
-		return false
-	}
 	return cast.ToInt(cast.ToString(x)) == cast.ToInt(cast.ToString(y))
 }
 
@@ -256,9 +302,6 @@ func (elem integer64) Bind(field *structs.Field, val interface{}) error {
 }
 
 func (elem integer64) Equal(x interface{}, y interface{}) bool {
-	if cast.ToInt64(cast.ToString(x)) == 0 || cast.ToInt64(cast.ToString(y)) == 0 {
-		return false
-	}
 	return cast.ToInt64(cast.ToString(x)) == cast.ToInt64(cast.ToString(y))
 }
 
@@ -288,9 +331,6 @@ func (elem floater32) Bind(field *structs.Field, val interface{}) error {
 }
 
 func (elem floater32) Equal(x interface{}, y interface{}) bool {
-	if cast.ToFloat32(cast.ToString(x)) == 0 || cast.ToFloat32(cast.ToString(y)) == 0 {
-		return false
-	}
 	return cast.ToFloat32(cast.ToString(x)) == cast.ToFloat32(cast.ToString(y))
 }
 
@@ -320,9 +360,6 @@ func (elem floater64) Bind(field *structs.Field, val interface{}) error {
 }
 
 func (elem floater64) Equal(x interface{}, y interface{}) bool {
-	if cast.ToFloat64(cast.ToString(x)) == 0 || cast.ToFloat64(cast.ToString(y)) == 0 {
-		return false
-	}
 	return cast.ToFloat64(cast.ToString(x)) == cast.ToFloat64(cast.ToString(y))
 }
 
@@ -574,6 +611,9 @@ func (elem zeroBool) TypeName() string {
 }
 
 func (elem zeroBool) RegisterPkId(val interface{}) string {
+	if v, ok := val.(zero.Bool); ok && v.Valid {
+		return strconv.FormatBool(v.Bool)
+	}
 	return ""
 }
 
@@ -614,7 +654,7 @@ func (elem boolean) TypeName() string {
 }
 
 func (elem boolean) RegisterPkId(val interface{}) string {
-	return ""
+	return strconv.FormatBool(cast.ToBool(indirect(val)))
 }
 
 func (elem boolean) Bind(field *structs.Field, val interface{}) error {
@@ -637,3 +677,154 @@ func (elem boolean) Bind(field *structs.Field, val interface{}) error {
 func (elem boolean) Equal(x interface{}, y interface{}) bool {
 	return cast.ToBool(x) == cast.ToBool(y)
 }
+
+/*
+----------------------------------------
+|
+|	json, jsonb
+|
+----------------------------------------
+*/
+type jsonType string
+
+func (elem jsonType) TypeName() string {
+	return string(elem)
+}
+
+func (elem jsonType) RegisterPkId(val interface{}) string {
+	raw, err := elem.DriverValue(val)
+	if err != nil || raw == nil {
+		return ""
+	}
+	return raw.(string)
+}
+
+// unmarshal into declared type of field (struct, map, slice, json.RawMessage) and pointer of them
+func (elem jsonType) Bind(field *structs.Field, val interface{}) error {
+	if val == nil {
+		return nil
+	}
+	var raw []byte
+	switch v := val.(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		bu, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		raw = bu
+	}
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil
+	}
+
+	fieldType := reflect.TypeOf(field.Value())
+	elemType := fieldType
+	if fieldType.Kind() == reflect.Ptr {
+		elemType = fieldType.Elem()
+	}
+	ptr := reflect.New(elemType)
+	if err := json.Unmarshal(raw, ptr.Interface()); err != nil {
+		return err
+	}
+	if fieldType.Kind() == reflect.Ptr {
+		return field.Set(ptr.Interface())
+	}
+	return field.Set(ptr.Elem().Interface())
+}
+
+// compare json document, not compare text
+func (elem jsonType) Equal(x interface{}, y interface{}) bool {
+	xRaw, xErr := elem.DriverValue(x)
+	yRaw, yErr := elem.DriverValue(y)
+	if xErr != nil || yErr != nil || xRaw == nil || yRaw == nil {
+		return false
+	}
+	var xDoc, yDoc interface{}
+	if json.Unmarshal([]byte(xRaw.(string)), &xDoc) != nil || json.Unmarshal([]byte(yRaw.(string)), &yDoc) != nil {
+		return false
+	}
+	return reflect.DeepEqual(xDoc, yDoc)
+}
+
+// encode as string, lib/pq send []byte as bytea
+func (elem jsonType) DriverValue(val interface{}) (driver.Value, error) {
+	if val == nil {
+		return nil, nil
+	}
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		if rv.IsNil() {
+			return nil, nil
+		}
+	}
+	if raw, ok := val.(json.RawMessage); ok {
+		return string(raw), nil
+	}
+	bu, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	return string(bu), nil
+}
+
+/*
+----------------------------------------
+|
+|	decimal (numeric)
+|
+----------------------------------------
+*/
+type decimal helper.Decimal
+
+func (elem decimal) TypeName() string {
+	return "decimal"
+}
+
+func (elem decimal) toDecimal(val interface{}) (helper.Decimal, bool) {
+	switch v := val.(type) {
+	case helper.Decimal:
+		return v, true
+	case *helper.Decimal:
+		if v != nil {
+			return *v, true
+		}
+	}
+	return helper.Decimal{}, false
+}
+
+// normalize scale so 1.50 and 1.5 is the same id
+func (elem decimal) RegisterPkId(val interface{}) string {
+	if v, ok := elem.toDecimal(val); ok {
+		return v.Normalize().String()
+	}
+	return ""
+}
+
+func (elem decimal) Bind(field *structs.Field, val interface{}) error {
+	if val == nil {
+		return nil
+	}
+	var dec helper.Decimal
+	if err := dec.Scan(val); err != nil {
+		return err
+	}
+	if reflect.TypeOf(field.Value()).Kind() == reflect.Ptr {
+		return field.Set(&dec)
+	}
+	return field.Set(dec)
+}
+
+func (elem decimal) Equal(x interface{}, y interface{}) bool {
+	xDec, xOK := elem.toDecimal(x)
+	yDec, yOK := elem.toDecimal(y)
+	if !xOK || !yOK {
+		return false
+	}
+	return xDec.Equal(yDec)
+}
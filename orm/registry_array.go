@@ -0,0 +1,282 @@
+package orm
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/fatih/structs"
+	"github.com/gofrs/uuid"
+	"github.com/spf13/cast"
+)
+
+/*
+----------------------------------------
+|
+|	postgres array (one dimension)
+|	field can be []T or []*T, NULL element is nil on []*T
+|
+----------------------------------------
+*/
+type arrayType struct {
+	name       string
+	elemType   reflect.Type
+	parseElem  func(text string) (interface{}, error)
+	formatElem func(val interface{}) string
+}
+
+var (
+	stringArray = arrayType{
+		name:     "[]string",
+		elemType: reflect.TypeOf(""),
+		parseElem: func(text string) (interface{}, error) {
+			return text, nil
+		},
+		formatElem: func(val interface{}) string {
+			return quoteArrayElem(cast.ToString(val))
+		},
+	}
+	intArray = arrayType{
+		name:     "[]int",
+		elemType: reflect.TypeOf(int(0)),
+		parseElem: func(text string) (interface{}, error) {
+			return strconv.Atoi(text)
+		},
+		formatElem: formatNumberElem,
+	}
+	int32Array = arrayType{
+		name:     "[]int32",
+		elemType: reflect.TypeOf(int32(0)),
+		parseElem: func(text string) (interface{}, error) {
+			val, err := strconv.ParseInt(text, 10, 32)
+			return int32(val), err
+		},
+		formatElem: formatNumberElem,
+	}
+	int64Array = arrayType{
+		name:     "[]int64",
+		elemType: reflect.TypeOf(int64(0)),
+		parseElem: func(text string) (interface{}, error) {
+			return strconv.ParseInt(text, 10, 64)
+		},
+		formatElem: formatNumberElem,
+	}
+	float32Array = arrayType{
+		name:     "[]float32",
+		elemType: reflect.TypeOf(float32(0)),
+		parseElem: func(text string) (interface{}, error) {
+			val, err := strconv.ParseFloat(text, 32)
+			return float32(val), err
+		},
+		formatElem: formatNumberElem,
+	}
+	float64Array = arrayType{
+		name:     "[]float64",
+		elemType: reflect.TypeOf(float64(0)),
+		parseElem: func(text string) (interface{}, error) {
+			return strconv.ParseFloat(text, 64)
+		},
+		formatElem: formatNumberElem,
+	}
+	boolArray = arrayType{
+		name:     "[]bool",
+		elemType: reflect.TypeOf(false),
+		parseElem: func(text string) (interface{}, error) {
+			switch strings.ToLower(text) {
+			case "t", "true":
+				return true, nil
+			case "f", "false":
+				return false, nil
+			}
+			return false, fmt.Errorf("invalid boolean %q", text)
+		},
+		formatElem: func(val interface{}) string {
+			if cast.ToBool(val) {
+				return "t"
+			}
+			return "f"
+		},
+	}
+	uuidArray = arrayType{
+		name:     "[]uuid",
+		elemType: reflect.TypeOf(uuid.UUID{}),
+		parseElem: func(text string) (interface{}, error) {
+			return uuid.FromString(text)
+		},
+		formatElem: func(val interface{}) string {
+			return quoteArrayElem(fmt.Sprint(val))
+		},
+	}
+)
+
+func (elem arrayType) TypeName() string {
+	return elem.name
+}
+
+func (elem arrayType) RegisterPkId(val interface{}) string {
+	literal, err := elem.DriverValue(val)
+	if err != nil || literal == nil {
+		return ""
+	}
+	return literal.(string)
+}
+
+func (elem arrayType) Bind(field *structs.Field, val interface{}) error {
+	if val == nil {
+		return nil
+	}
+	items, err := parseArrayLiteral(cast.ToString(val))
+	if err != nil {
+		return fmt.Errorf("%s: %w", elem.name, err)
+	}
+
+	fieldType := reflect.TypeOf(field.Value())
+	if fieldType.Kind() != reflect.Slice {
+		return fmt.Errorf("%w: %s can not bind into %s", ErrInvalidRegistry, elem.name, fieldType)
+	}
+	itemType := fieldType.Elem()
+	baseType := itemType
+	if itemType.Kind() == reflect.Ptr {
+		baseType = itemType.Elem()
+	}
+	if !elem.elemType.ConvertibleTo(baseType) {
+		return fmt.Errorf("%w: %s can not bind into %s", ErrInvalidRegistry, elem.name, fieldType)
+	}
+
+	slice := reflect.MakeSlice(fieldType, 0, len(items))
+	for _, item := range items {
+		if item == nil {
+			slice = reflect.Append(slice, reflect.Zero(itemType))
+			continue
+		}
+		parsed, err := elem.parseElem(*item)
+		if err != nil {
+			return fmt.Errorf("%s: %w", elem.name, err)
+		}
+		itemVal := reflect.ValueOf(parsed).Convert(baseType)
+		if itemType.Kind() == reflect.Ptr {
+			ptr := reflect.New(baseType)
+			ptr.Elem().Set(itemVal)
+			itemVal = ptr
+		}
+		slice = reflect.Append(slice, itemVal)
+	}
+	return field.Set(slice.Interface())
+}
+
+func (elem arrayType) Equal(x interface{}, y interface{}) bool {
+	xLiteral, xErr := elem.DriverValue(x)
+	yLiteral, yErr := elem.DriverValue(y)
+	if xErr != nil || yErr != nil || xLiteral == nil || yLiteral == nil {
+		return false
+	}
+	return xLiteral == yLiteral
+}
+
+// encode as array literal {"a","b",NULL}, nil slice is NULL
+func (elem arrayType) DriverValue(val interface{}) (driver.Value, error) {
+	if isNil(val) {
+		return nil, nil
+	}
+	slice := reflect.ValueOf(val)
+	if slice.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("%w: %s can not encode %T", ErrInvalidRegistry, elem.name, val)
+	}
+	if slice.IsNil() {
+		return nil, nil
+	}
+
+	var items = make([]string, 0, slice.Len())
+	for index := 0; index < slice.Len(); index++ {
+		item := slice.Index(index)
+		if item.Kind() == reflect.Ptr {
+			if item.IsNil() {
+				items = append(items, "NULL")
+				continue
+			}
+			item = item.Elem()
+		}
+		items = append(items, elem.formatElem(item.Convert(elem.elemType).Interface()))
+	}
+	return "{" + strings.Join(items, ",") + "}", nil
+}
+
+func formatNumberElem(val interface{}) string {
+	return cast.ToString(val)
+}
+
+func quoteArrayElem(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\\`)
+	text = strings.ReplaceAll(text, `"`, `\"`)
+	return `"` + text + `"`
+}
+
+/*
+parseArrayLiteral parse one dimension postgres array text
+example {a,"b c",NULL,"x\"y"}, nil item is NULL
+*/
+func parseArrayLiteral(literal string) ([]*string, error) {
+	literal = strings.TrimSpace(literal)
+	/* skip dimension decoration [1:3]={...} */
+	if strings.HasPrefix(literal, "[") {
+		if index := strings.Index(literal, "="); index != -1 {
+			literal = literal[index+1:]
+		}
+	}
+	if len(literal) < 2 || literal[0] != '{' || literal[len(literal)-1] != '}' {
+		return nil, fmt.Errorf("invalid array literal %q", literal)
+	}
+	body := literal[1 : len(literal)-1]
+	var items = make([]*string, 0)
+	if strings.TrimSpace(body) == "" {
+		return items, nil
+	}
+
+	var (
+		builder strings.Builder
+		quoted  bool
+		inQuote bool
+		escape  bool
+	)
+	var appendItem = func() {
+		text := builder.String()
+		builder.Reset()
+		if !quoted {
+			text = strings.TrimSpace(text)
+			if strings.EqualFold(text, "NULL") {
+				items = append(items, nil)
+				return
+			}
+		}
+		items = append(items, &text)
+		quoted = false
+	}
+	for _, char := range body {
+		switch {
+		case escape:
+			builder.WriteRune(char)
+			escape = false
+		case char == '\\':
+			escape = true
+		case unicode.IsSpace(char) && !inQuote && (quoted || builder.Len() == 0):
+			continue
+		case char == '"':
+			inQuote = !inQuote
+			quoted = true
+		case char == '{' && !inQuote:
+			return nil, fmt.Errorf("multi dimension array is not supported %q", literal)
+		case char == ',' && !inQuote:
+			appendItem()
+		default:
+			builder.WriteRune(char)
+		}
+	}
+	if inQuote || escape {
+		return nil, fmt.Errorf("invalid array literal %q", literal)
+	}
+	appendItem()
+	return items, nil
+}
@@ -0,0 +1,261 @@
+package orm
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/fatih/structs"
+	"github.com/spf13/cast"
+)
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	bytesType = reflect.TypeOf([]byte(nil))
+)
+
+/*
+inferRegistry find registry from go type of field when type tag is absent
+T or *T of builtin scalar, time.Time, []byte and sql.Scanner (sql.Null*, helper types)
+*/
+func inferRegistry(fieldType reflect.Type) (Registry, bool) {
+	if fieldType == nil {
+		return nil, false
+	}
+	if fieldType == bytesType {
+		return bytesRegistry{}, true
+	}
+	baseType := fieldType
+	if baseType.Kind() == reflect.Ptr {
+		baseType = baseType.Elem()
+	}
+	if reflect.PtrTo(baseType).Implements(scannerType) {
+		return scannerRegistry{name: baseType.String(), modelType: baseType}, true
+	}
+	if baseType == timeType {
+		return timeRegistry{}, true
+	}
+	switch baseType.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return primitiveRegistry{kind: baseType.Kind()}, true
+	}
+	return nil, false
+}
+
+// indirect return nil for nil pointer, value of pointer otherwise
+func indirect(val interface{}) interface{} {
+	if val == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(val)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		return rv.Elem().Interface()
+	}
+	return val
+}
+
+// setFieldValue set value into field T or *T, value is converted to T
+func setFieldValue(field *structs.Field, val reflect.Value) error {
+	fieldType := reflect.TypeOf(field.Value())
+	if fieldType.Kind() == reflect.Ptr {
+		ptr := reflect.New(fieldType.Elem())
+		ptr.Elem().Set(val.Convert(fieldType.Elem()))
+		return field.Set(ptr.Interface())
+	}
+	return field.Set(val.Convert(fieldType).Interface())
+}
+
+/*
+----------------------------------------
+|
+|	builtin scalar (string, bool, int*, uint*, float*)
+|
+----------------------------------------
+*/
+type primitiveRegistry struct {
+	kind reflect.Kind
+}
+
+func (elem primitiveRegistry) TypeName() string {
+	return elem.kind.String()
+}
+
+func (elem primitiveRegistry) RegisterPkId(val interface{}) string {
+	val = indirect(val)
+	if val == nil {
+		return ""
+	}
+	return cast.ToString(val)
+}
+
+func (elem primitiveRegistry) Bind(field *structs.Field, val interface{}) error {
+	if val == nil {
+		return nil
+	}
+	var text, isText = val.(string)
+	if bu, ok := val.([]byte); ok {
+		text, isText = string(bu), true
+	}
+
+	var parsed interface{}
+	var err error
+	switch elem.kind {
+	case reflect.String:
+		parsed = cast.ToString(val)
+	case reflect.Bool:
+		if isText {
+			parsed, err = strconv.ParseBool(text)
+		} else {
+			parsed, err = cast.ToBoolE(val)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if isText {
+			parsed, err = strconv.ParseInt(text, 10, 64)
+		} else {
+			parsed, err = cast.ToInt64E(val)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if isText {
+			parsed, err = strconv.ParseUint(text, 10, 64)
+		} else {
+			parsed, err = cast.ToUint64E(val)
+		}
+	case reflect.Float32, reflect.Float64:
+		if isText {
+			parsed, err = strconv.ParseFloat(text, 64)
+		} else {
+			parsed, err = cast.ToFloat64E(val)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	fieldType := reflect.TypeOf(field.Value())
+	baseType := fieldType
+	if baseType.Kind() == reflect.Ptr {
+		baseType = baseType.Elem()
+	}
+	target := reflect.New(baseType).Elem()
+	switch elem.kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if target.OverflowInt(parsed.(int64)) {
+			return fmt.Errorf("value %v overflow %s", parsed, baseType)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if target.OverflowUint(parsed.(uint64)) {
+			return fmt.Errorf("value %v overflow %s", parsed, baseType)
+		}
+	}
+	return setFieldValue(field, reflect.ValueOf(parsed))
+}
+
+func (elem primitiveRegistry) Equal(x interface{}, y interface{}) bool {
+	x, y = indirect(x), indirect(y)
+	if x == nil || y == nil {
+		return false
+	}
+	return cast.ToString(x) == cast.ToString(y)
+}
+
+/*
+----------------------------------------
+|
+|	time.Time
+|
+----------------------------------------
+*/
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+type timeRegistry struct{}
+
+func (elem timeRegistry) TypeName() string {
+	return "time.Time"
+}
+
+func (elem timeRegistry) RegisterPkId(val interface{}) string {
+	t, ok := indirect(val).(time.Time)
+	if !ok || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func (elem timeRegistry) Bind(field *structs.Field, val interface{}) error {
+	if val == nil {
+		return nil
+	}
+	if t, ok := val.(time.Time); ok {
+		return setFieldValue(field, reflect.ValueOf(t))
+	}
+	text := cast.ToString(val)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			return setFieldValue(field, reflect.ValueOf(t))
+		}
+	}
+	return fmt.Errorf("cannot parse %q into time.Time", text)
+}
+
+func (elem timeRegistry) Equal(x interface{}, y interface{}) bool {
+	xTime, xOK := indirect(x).(time.Time)
+	yTime, yOK := indirect(y).(time.Time)
+	if !xOK || !yOK {
+		return false
+	}
+	return xTime.Equal(yTime)
+}
+
+/*
+----------------------------------------
+|
+|	[]byte
+|
+----------------------------------------
+*/
+type bytesRegistry struct{}
+
+func (elem bytesRegistry) TypeName() string {
+	return "[]byte"
+}
+
+func (elem bytesRegistry) RegisterPkId(val interface{}) string {
+	bu, ok := val.([]byte)
+	if !ok || bu == nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", bu)
+}
+
+func (elem bytesRegistry) Bind(field *structs.Field, val interface{}) error {
+	switch v := val.(type) {
+	case []byte:
+		return field.Set(append([]byte(nil), v...))
+	case string:
+		return field.Set([]byte(v))
+	}
+	return nil
+}
+
+func (elem bytesRegistry) Equal(x interface{}, y interface{}) bool {
+	xBytes, xOK := x.([]byte)
+	yBytes, yOK := y.([]byte)
+	if !xOK || !yOK || xBytes == nil || yBytes == nil {
+		return false
+	}
+	return bytes.Equal(xBytes, yBytes)
+}
@@ -0,0 +1,238 @@
+package orm_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	helperModel "github.com/Pheethy/psql/helper"
+	"github.com/Pheethy/psql/orm"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v2"
+)
+
+type Setting struct {
+	Theme string `json:"theme"`
+	Size  int    `json:"size"`
+}
+
+type Profile struct {
+	TableName struct{}               `json:"-" db:"profiles" pk:"ID"`
+	ID        int64                  `json:"id" db:"id" type:"int64"`
+	Setting   *Setting               `json:"setting" db:"setting" type:"jsonb"`
+	Meta      map[string]interface{} `json:"meta" db:"meta" type:"jsonb"`
+	Tags      []string               `json:"tags" db:"tags" type:"json"`
+	Raw       json.RawMessage        `json:"raw" db:"raw" type:"jsonb"`
+}
+
+type Article struct {
+	TableName struct{}     `json:"-" db:"articles" pk:"ID"`
+	ID        int64        `json:"id" db:"id" type:"int64"`
+	Tags      []string     `json:"tags" db:"tags" type:"[]string"`
+	Notes     []*string    `json:"notes" db:"notes" type:"[]string"`
+	Scores    []int64      `json:"scores" db:"scores" type:"[]int64"`
+	Flags     []bool       `json:"flags" db:"flags" type:"[]bool"`
+	Authors   []*uuid.UUID `json:"authors" db:"authors" type:"[]uuid"`
+}
+
+type Wallet struct {
+	TableName struct{}             `json:"-" db:"wallets" pk:"ID"`
+	ID        int64                `json:"id" db:"id" type:"int64"`
+	Balance   helperModel.Decimal  `json:"balance" db:"balance" type:"decimal"`
+	Limit     *helperModel.Decimal `json:"limit" db:"limit" type:"decimal"`
+}
+
+type Level int8
+
+type Customer struct {
+	TableName struct{}       `json:"-" db:"customers" pk:"ID"`
+	ID        int64          `json:"id" db:"id"`
+	Name      string         `json:"name" db:"name"`
+	Nickname  *string        `json:"nickname" db:"nickname"`
+	Age       *int           `json:"age" db:"age"`
+	Level     Level          `json:"level" db:"level"`
+	Rate      float64        `json:"rate" db:"rate"`
+	Active    bool           `json:"active" db:"active"`
+	BornAt    time.Time      `json:"born_at" db:"born_at"`
+	DeletedAt *time.Time     `json:"deleted_at" db:"deleted_at"`
+	Email     sql.NullString `json:"email" db:"email"`
+	Avatar    []byte         `json:"avatar" db:"avatar"`
+	Code      string         `json:"code" db:"code" type:"-"`
+}
+
+func TestRegistry(t *testing.T) {
+	t.Run("success_json_bind_declared_type", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		defer db.Close()
+
+		dbmock.ExpectQuery(`SELECT (.+) profiles`).WillReturnRows(
+			sqlmock.NewRows([]string{"profiles.id", "profiles.setting", "profiles.meta", "profiles.tags", "profiles.raw"}).
+				AddRow(1, []byte(`{"theme":"dark","size":12}`), []byte(`{"a":1}`), `["x","y"]`, []byte(`{"b": true}`)).
+				AddRow(2, nil, []byte(`null`), nil, nil),
+		)
+		rows, err := db.Queryx(`SELECT * FROM profiles`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		mapper, err := orm.Orm(new(Profile), rows, orm.NewMapperOption())
+		assert.NoError(t, err)
+		profiles := mapper.GetData().([]*Profile)
+		assert.Len(t, profiles, 2)
+		assert.Equal(t, &Setting{Theme: "dark", Size: 12}, profiles[0].Setting)
+		assert.Equal(t, map[string]interface{}{"a": float64(1)}, profiles[0].Meta)
+		assert.Equal(t, []string{"x", "y"}, profiles[0].Tags)
+		assert.JSONEq(t, `{"b": true}`, string(profiles[0].Raw))
+		assert.Nil(t, profiles[1].Setting)
+		assert.Nil(t, profiles[1].Meta)
+		assert.Nil(t, profiles[1].Tags)
+		assert.Nil(t, profiles[1].Raw)
+	})
+
+	t.Run("success_json_insert_encode", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		defer db.Close()
+
+		profile := &Profile{Setting: &Setting{Theme: "dark"}, Tags: []string{"x"}}
+		sql := `INSERT INTO "profiles" ("id","setting","meta","tags","raw") VALUES (DEFAULT,$1,$2,$3,$4)`
+		dbmock.ExpectQuery(regexp.QuoteMeta(sql)).
+			WithArgs(`{"theme":"dark","size":0}`, nil, `["x"]`, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+
+		err := orm.Insert(context.Background(), db, profile)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(10), profile.ID)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("success_array_bind_with_null_and_quote", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		defer db.Close()
+
+		author, _ := uuid.NewV4()
+		dbmock.ExpectQuery(`SELECT (.+) articles`).WillReturnRows(
+			sqlmock.NewRows([]string{"articles.id", "articles.tags", "articles.notes", "articles.scores", "articles.flags", "articles.authors"}).
+				AddRow(1, []byte(`{go,"hello, world","say \"hi\"",NULL}`), []byte(`{a,NULL}`), []byte(`{1,-2,3}`), []byte(`{t,f}`), []byte(`{`+author.String()+`,NULL}`)).
+				AddRow(2, []byte(`{}`), nil, nil, nil, nil),
+		)
+		rows, err := db.Queryx(`SELECT * FROM articles`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		mapper, err := orm.Orm(new(Article), rows, orm.NewMapperOption())
+		assert.NoError(t, err)
+		articles := mapper.GetData().([]*Article)
+		assert.Len(t, articles, 2)
+		assert.Equal(t, []string{"go", "hello, world", `say "hi"`, ""}, articles[0].Tags)
+		assert.Equal(t, "a", *articles[0].Notes[0])
+		assert.Nil(t, articles[0].Notes[1])
+		assert.Equal(t, []int64{1, -2, 3}, articles[0].Scores)
+		assert.Equal(t, []bool{true, false}, articles[0].Flags)
+		assert.Equal(t, author.String(), articles[0].Authors[0].String())
+		assert.Nil(t, articles[0].Authors[1])
+		assert.Equal(t, []string{}, articles[1].Tags)
+		assert.Nil(t, articles[1].Scores)
+	})
+
+	t.Run("success_array_insert_encode", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		defer db.Close()
+
+		note := `a"b`
+		article := &Article{Tags: []string{"go", "a,b"}, Notes: []*string{&note, nil}, Scores: []int64{}}
+		sql := `INSERT INTO "articles" ("id","tags","notes","scores","flags","authors") VALUES (DEFAULT,$1,$2,$3,$4,$5)`
+		dbmock.ExpectQuery(regexp.QuoteMeta(sql)).
+			WithArgs(`{"go","a,b"}`, `{"a\"b",NULL}`, `{}`, nil, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+		err := orm.Insert(context.Background(), db, article)
+		assert.NoError(t, err)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("success_decimal_keep_precision_and_scale", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		defer db.Close()
+
+		dbmock.ExpectQuery(`SELECT (.+) wallets`).WillReturnRows(
+			sqlmock.NewRows([]string{"wallets.id", "wallets.balance", "wallets.limit"}).
+				AddRow(1, []byte(`12345678901234.1000`), nil).
+				AddRow(2, []byte(`-0.0500`), []byte(`100`)),
+		)
+		rows, err := db.Queryx(`SELECT * FROM wallets`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		mapper, err := orm.Orm(new(Wallet), rows, orm.NewMapperOption())
+		assert.NoError(t, err)
+		wallets := mapper.GetData().([]*Wallet)
+		assert.Len(t, wallets, 2)
+		assert.Equal(t, "12345678901234.1000", wallets[0].Balance.String())
+		assert.Nil(t, wallets[0].Limit)
+		assert.Equal(t, "-0.0500", wallets[1].Balance.String())
+		assert.Equal(t, "100", wallets[1].Limit.String())
+
+		bu, err := json.Marshal(wallets[0])
+		assert.NoError(t, err)
+		assert.Equal(t, `{"id":1,"balance":12345678901234.1000,"limit":null}`, string(bu))
+
+		expected, _ := helperModel.NewDecimalFromString("-0.05")
+		assert.True(t, wallets[1].Balance.Equal(expected))
+	})
+
+	t.Run("success_infer_registry_without_type_tag", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		defer db.Close()
+
+		bornAt := time.Date(1990, 1, 2, 3, 4, 5, 0, time.UTC)
+		dbmock.ExpectQuery(`SELECT (.+) customers`).WillReturnRows(
+			sqlmock.NewRows([]string{
+				"customers.id", "customers.name", "customers.nickname", "customers.age", "customers.level", "customers.rate",
+				"customers.active", "customers.born_at", "customers.deleted_at", "customers.email", "customers.avatar", "customers.code",
+			}).
+				AddRow(1, []byte("Kintara"), "Kin", []byte("31"), 3, 1.5, true, bornAt, nil, "kin@mail.com", []byte{0x1, 0x2}, "C001").
+				AddRow(2, "Bob", nil, nil, int64(-1), []byte("0.25"), []byte("f"), "1990-01-02 03:04:05Z", bornAt, nil, nil, "C002"),
+		)
+		rows, err := db.Queryx(`SELECT * FROM customers`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		mapper, err := orm.Orm(new(Customer), rows, orm.NewMapperOption())
+		assert.NoError(t, err)
+		customers := mapper.GetData().([]*Customer)
+		assert.Len(t, customers, 2)
+
+		assert.Equal(t, int64(1), customers[0].ID)
+		assert.Equal(t, "Kintara", customers[0].Name)
+		assert.Equal(t, "Kin", *customers[0].Nickname)
+		assert.Equal(t, 31, *customers[0].Age)
+		assert.Equal(t, Level(3), customers[0].Level)
+		assert.Equal(t, 1.5, customers[0].Rate)
+		assert.True(t, customers[0].Active)
+		assert.True(t, bornAt.Equal(customers[0].BornAt))
+		assert.Nil(t, customers[0].DeletedAt)
+		assert.Equal(t, sql.NullString{String: "kin@mail.com", Valid: true}, customers[0].Email)
+		assert.Equal(t, []byte{0x1, 0x2}, customers[0].Avatar)
+		assert.Empty(t, customers[0].Code)
+
+		assert.Nil(t, customers[1].Nickname)
+		assert.Nil(t, customers[1].Age)
+		assert.Equal(t, Level(-1), customers[1].Level)
+		assert.Equal(t, 0.25, customers[1].Rate)
+		assert.False(t, customers[1].Active)
+		assert.True(t, bornAt.Equal(customers[1].BornAt))
+		assert.True(t, bornAt.Equal(*customers[1].DeletedAt))
+		assert.False(t, customers[1].Email.Valid)
+	})
+}
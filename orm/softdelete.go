@@ -0,0 +1,115 @@
+package orm
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+/*
+SoftDeleteCondition is condition of soft delete scope of MapperOption for root WHERE without WHERE keyword,
+`"orders"."deleted_at" IS NULL` by default, `IS NOT NULL` with SetOnlyDeleted and "" with SetWithDeleted
+or model without softdelete field. alias is table alias of root in query
+*/
+func SoftDeleteCondition(model interface{}, options MapperOption, alias ...string) string {
+	return softDeleteConditionOf(model, options.softDeleteScope, alias...)
+}
+
+/*
+SoftDeleteJoinCondition is condition of relation for JOIN ON, deleted relation is skipped
+with SetOnlyDeleted too (only deleted is scope of root), example
+LEFT JOIN users creator ON creator.id = orders.creator_id AND orm.SoftDeleteJoinCondition(new(User), options, "creator")
+*/
+func SoftDeleteJoinCondition(model interface{}, options MapperOption, alias ...string) string {
+	return softDeleteConditionOf(model, relationScope(options.softDeleteScope), alias...)
+}
+
+func softDeleteConditionOf(model interface{}, scope SoftDeleteScopes, alias ...string) string {
+	meta, err := getModelMeta(model)
+	if err != nil {
+		return ""
+	}
+	table := meta.table
+	if len(alias) > 0 && alias[0] != "" {
+		table = alias[0]
+	}
+	return softDeleteCondition(meta, table, scope)
+}
+
+// relationScope only deleted is scope of root, deleted relation is skipped
+func relationScope(scope SoftDeleteScopes) SoftDeleteScopes {
+	if scope == SOFT_DELETE_SCOPE_ONLY {
+		return ""
+	}
+	return scope
+}
+
+func softDeleteCondition(meta *modelMeta, table string, scope SoftDeleteScopes) string {
+	if meta.softDelete == nil {
+		return ""
+	}
+	return softDeleteColumnCondition(meta, quoteIdentifier(table)+"."+quoteIdentifier(meta.softDelete.column), scope)
+}
+
+// softDeleteColumnCondition condition of scope on column which is already quoted
+func softDeleteColumnCondition(meta *modelMeta, column string, scope SoftDeleteScopes) string {
+	if meta.softDelete == nil || scope == SOFT_DELETE_SCOPE_WITH {
+		return ""
+	}
+	switch {
+	case meta.softDelete.isBool() && scope == SOFT_DELETE_SCOPE_ONLY:
+		return fmt.Sprintf("%s IS TRUE", column)
+	case meta.softDelete.isBool():
+		return fmt.Sprintf("%s IS NOT TRUE", column)
+	case scope == SOFT_DELETE_SCOPE_ONLY:
+		return fmt.Sprintf("%s IS NOT NULL", column)
+	}
+	return fmt.Sprintf("%s IS NULL", column)
+}
+
+func (f *fieldMeta) isBool() bool {
+	fieldType := f.fieldType
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	return fieldType.Kind() == reflect.Bool
+}
+
+// isDeleted bool field is deleted when true, other field is deleted when not null and not zero
+func (f *fieldMeta) isDeleted(elem reflect.Value, options MapperOption) bool {
+	val := f.value(elem)
+	if f.isBool() {
+		rv := reflect.Indirect(reflect.ValueOf(val))
+		return rv.IsValid() && rv.Bool()
+	}
+	registry, err := f.getRegistry(options)
+	if err != nil || isNullValue(registry, val) {
+		return false
+	}
+	return !reflect.ValueOf(val).IsZero()
+}
+
+// deletedValue is value of soft delete column when delete, true or deletedAt (now in helper.TimeLocation same as autoUpdateTime)
+func (f *fieldMeta) deletedValue(deletedAt time.Time) interface{} {
+	if f.isBool() {
+		return true
+	}
+	return deletedAt
+}
+
+/*
+isVisible filter soft deleted model of rows in memory, fallback of query which does not use
+SoftDeleteCondition / SoftDeleteJoinCondition. deleted root and relation is skipped by default,
+SetOnlyDeleted keep only deleted root and SetWithDeleted keep every model.
+model which soft delete column is not selected is always visible
+*/
+func (m modelStruct) isVisible(elem reflect.Value, options MapperOption) bool {
+	if m.meta.softDelete == nil || !m.isSoftDeleteSelected || options.softDeleteScope == SOFT_DELETE_SCOPE_WITH {
+		return true
+	}
+	deleted := m.meta.softDelete.isDeleted(elem, options)
+	if m.isRoot && options.softDeleteScope == SOFT_DELETE_SCOPE_ONLY {
+		return deleted
+	}
+	return !deleted
+}
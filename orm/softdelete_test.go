@@ -0,0 +1,225 @@
+package orm_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/Pheethy/psql/helper"
+	"github.com/Pheethy/psql/orm"
+	"github.com/Pheethy/sqlx"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v2"
+)
+
+type Post struct {
+	TableName struct{}   `json:"-" db:"posts" pk:"ID"`
+	ID        int        `json:"id" db:"id"`
+	Title     string     `json:"title" db:"title"`
+	DeletedAt *time.Time `json:"deleted_at" db:"deleted_at" softdelete:"true"`
+	Replies   []*Reply   `json:"replies" db:"-" fk:"fk_field1:ID,fk_field2:PostID"`
+}
+
+type Reply struct {
+	TableName struct{} `json:"-" db:"replies" pk:"ID"`
+	ID        int      `json:"id" db:"id"`
+	PostID    int      `json:"post_id" db:"post_id"`
+	IsDeleted bool     `json:"is_deleted" db:"is_deleted" softdelete:"true"`
+}
+
+type Draft struct {
+	TableName struct{}   `json:"-" db:"drafts" pk:"ID"`
+	ID        int        `json:"id" db:"id"`
+	UpdatedAt *time.Time `json:"updated_at" db:"updated_at" autoUpdateTime:"true"`
+	Version   int        `json:"version" db:"version" version:"true"`
+	DeletedAt *time.Time `json:"deleted_at" db:"deleted_at" softdelete:"true"`
+}
+
+type InvalidSoftDeleteArticle struct {
+	TableName struct{}   `json:"-" db:"posts" pk:"ID"`
+	ID        int        `json:"id" db:"id"`
+	DeletedAt *time.Time `json:"deleted_at" softdelete:"true"`
+}
+
+func TestSoftDelete(t *testing.T) {
+	var columns = []string{"posts.id", "posts.title", "posts.deleted_at", "replies.id", "replies.post_id", "replies.is_deleted"}
+	var deletedAt = time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+	var mapPosts = func(t *testing.T, options orm.MapperOption) []*Post {
+		db, dbmock := newDB(t)
+		dbmock.ExpectQuery(`SELECT (.+) posts`).WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, "Live", nil, 10, 1, false).
+			AddRow(1, "Live", nil, 11, 1, true).
+			AddRow(2, "Removed", deletedAt, 12, 2, false).
+			AddRow(2, "Removed", deletedAt, 13, 2, true),
+		)
+		rows, err := db.Queryx(`SELECT * FROM posts`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		mapper, err := orm.Orm(new(Post), rows, options)
+		assert.NoError(t, err)
+		return mapper.GetData().([]*Post)
+	}
+
+	t.Run("success_skip_deleted_root_and_relation", func(t *testing.T) {
+		posts := mapPosts(t, orm.NewMapperOption())
+		assert.Len(t, posts, 1)
+		assert.Equal(t, 1, posts[0].ID)
+		assert.Len(t, posts[0].Replies, 1)
+		assert.Equal(t, 10, posts[0].Replies[0].ID)
+	})
+
+	t.Run("success_with_deleted", func(t *testing.T) {
+		posts := mapPosts(t, orm.NewMapperOption().SetWithDeleted())
+		assert.Len(t, posts, 2)
+		assert.Len(t, posts[0].Replies, 2)
+		assert.Len(t, posts[1].Replies, 2)
+	})
+
+	t.Run("success_only_deleted_root", func(t *testing.T) {
+		posts := mapPosts(t, orm.NewMapperOption().SetOnlyDeleted())
+		assert.Len(t, posts, 1)
+		assert.Equal(t, 2, posts[0].ID)
+		assert.Len(t, posts[0].Replies, 1)
+		assert.Equal(t, 12, posts[0].Replies[0].ID)
+	})
+
+	t.Run("success_condition_of_scope", func(t *testing.T) {
+		assert.Equal(t, `"posts"."deleted_at" IS NULL`, orm.SoftDeleteCondition(new(Post), orm.NewMapperOption()))
+		assert.Equal(t, `"a"."deleted_at" IS NOT NULL`, orm.SoftDeleteCondition(new(Post), orm.NewMapperOption().SetOnlyDeleted(), "a"))
+		assert.Equal(t, `"replies"."is_deleted" IS NOT TRUE`, orm.SoftDeleteCondition(new(Reply), orm.NewMapperOption()))
+		assert.Empty(t, orm.SoftDeleteCondition(new(Post), orm.NewMapperOption().SetWithDeleted()))
+		assert.Empty(t, orm.SoftDeleteCondition(new(Menu), orm.NewMapperOption()))
+
+		/* only deleted is scope of root, joined relation skip deleted */
+		assert.Equal(t, `"r"."is_deleted" IS NOT TRUE`, orm.SoftDeleteJoinCondition(new(Reply), orm.NewMapperOption().SetOnlyDeleted(), "r"))
+		assert.Empty(t, orm.SoftDeleteJoinCondition(new(Reply), orm.NewMapperOption().SetWithDeleted()))
+	})
+
+	t.Run("success_paginate_filter_deleted_root", func(t *testing.T) {
+		query, err := orm.PaginateRootQuery(new(Post), `SELECT * FROM posts`, 1, 10)
+		assert.NoError(t, err)
+		assert.Contains(t, query, `FROM (SELECT * FROM posts) paginate_query WHERE paginate_query."posts.deleted_at" IS NULL) paginate_rank`)
+
+		query, err = orm.PaginateRootQueryWithOption(orm.NewMapperOption().SetOnlyDeleted(), new(Post), `SELECT * FROM posts`, 1, 10)
+		assert.NoError(t, err)
+		assert.Contains(t, query, `WHERE paginate_query."posts.deleted_at" IS NOT NULL`)
+
+		query, err = orm.PaginateRootQueryWithOption(orm.NewMapperOption().SetWithDeleted(), new(Post), `SELECT * FROM posts`, 1, 10)
+		assert.NoError(t, err)
+		assert.NotContains(t, query, "deleted_at")
+	})
+
+	t.Run("success_delete_is_update", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		dbmock.ExpectExec(regexp.QuoteMeta(`UPDATE "posts" SET "deleted_at" = $1 WHERE ("id") IN (($2),($3)) AND "deleted_at" IS NULL`)).
+			WithArgs(sqlmock.AnyArg(), 1, 2).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		dbmock.ExpectExec(regexp.QuoteMeta(`UPDATE "replies" SET "is_deleted" = $1 WHERE ("id") IN (($2)) AND "is_deleted" IS NOT TRUE`)).
+			WithArgs(true, 10).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		dbmock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "posts" WHERE ("id") IN (($1))`)).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		posts := []*Post{{ID: 1}, {ID: 2}}
+		assert.NoError(t, orm.DeleteBatch(context.Background(), db, posts))
+		if assert.NotNil(t, posts[0].DeletedAt) {
+			/* same clock as autoUpdateTime */
+			assert.Equal(t, helper.TimeLocation, posts[0].DeletedAt.Location())
+		}
+		assert.NotNil(t, posts[1].DeletedAt)
+
+		reply := &Reply{ID: 10}
+		assert.NoError(t, orm.Delete(context.Background(), db, reply))
+		assert.True(t, reply.IsDeleted)
+
+		assert.NoError(t, orm.ForceDelete(context.Background(), db, &Post{ID: 1}))
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("success_delete_bump_update_time_and_version", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		dbmock.ExpectExec(regexp.QuoteMeta(`UPDATE "drafts" SET "deleted_at" = $1,"updated_at" = $2,"version" = "version" + 1 `+
+			`WHERE ("id","version") IN (($3,$4),($5,$6)) AND "deleted_at" IS NULL`)).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 1, 3, 2, 1).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		drafts := []*Draft{{ID: 1, Version: 3}, {ID: 2, Version: 1}}
+		assert.NoError(t, orm.DeleteBatch(context.Background(), db, drafts))
+		if assert.NotNil(t, drafts[0].DeletedAt) && assert.NotNil(t, drafts[0].UpdatedAt) {
+			assert.Equal(t, *drafts[0].DeletedAt, *drafts[0].UpdatedAt)
+		}
+		assert.Equal(t, 4, drafts[0].Version)
+		assert.Equal(t, 2, drafts[1].Version)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("error_delete_stale_version", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		dbmock.ExpectExec(regexp.QuoteMeta(`UPDATE "drafts"`)).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 1, 3).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		draft := &Draft{ID: 1, Version: 3}
+		assert.ErrorIs(t, orm.Delete(context.Background(), db, draft), orm.ErrStaleObject)
+		assert.Nil(t, draft.DeletedAt)
+		assert.Equal(t, 3, draft.Version)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("warning_paginate_total_with_deleted_root", func(t *testing.T) {
+		var newRows = func(t *testing.T) *sqlx.Rows {
+			db, dbmock := newDB(t)
+			dbmock.ExpectQuery(`SELECT (.+) posts`).WillReturnRows(
+				sqlmock.NewRows([]string{"posts.id", "posts.title", "posts.deleted_at", "root_rank", "total_row"}).
+					AddRow(1, "Live", nil, 1, 2).
+					AddRow(2, "Removed", deletedAt, 2, 2),
+			)
+			rows, err := db.Queryx(`SELECT * FROM posts`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { rows.Close() })
+			return rows
+		}
+
+		/* query without SoftDeleteCondition, total_row count deleted root */
+		mapper, err := orm.Orm(new(Post), newRows(t), orm.NewMapperOption())
+		assert.NoError(t, err)
+		assert.Len(t, mapper.GetData().([]*Post), 1)
+		if assert.Len(t, mapper.GetWarnings(), 1) {
+			assert.ErrorIs(t, mapper.GetWarnings()[0], orm.ErrSoftDeletedRoot)
+		}
+
+		_, err = orm.Orm(new(Post), newRows(t), orm.NewMapperOption().SetStrict())
+		assert.ErrorIs(t, err, orm.ErrSoftDeletedRoot)
+	})
+
+	t.Run("success_preload_skip_deleted", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		dbmock.ExpectQuery(`SELECT (.+) posts`).WillReturnRows(
+			sqlmock.NewRows([]string{"posts.id", "posts.title", "posts.deleted_at"}).AddRow(1, "Live", nil),
+		)
+		dbmock.ExpectQuery(regexp.QuoteMeta(`WHERE "replies"."post_id" = ANY($1) AND "replies"."is_deleted" IS NOT TRUE`)).
+			WithArgs(`{1}`).
+			WillReturnRows(sqlmock.NewRows([]string{"replies.id", "replies.post_id", "replies.is_deleted"}).AddRow(10, 1, false))
+
+		rows, err := db.Queryx(`SELECT * FROM posts`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		mapper, err := orm.Orm(new(Post), rows, orm.NewMapperOption().SetPreload(db, "Replies"))
+		assert.NoError(t, err)
+		assert.Len(t, mapper.GetData().([]*Post)[0].Replies, 1)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("error_softdelete_without_column", func(t *testing.T) {
+		assert.ErrorIs(t, orm.ValidateModels(new(InvalidSoftDeleteArticle)), orm.ErrInvalidSoftDelete)
+		assert.NoError(t, orm.ValidateModels(new(Post)))
+	})
+}
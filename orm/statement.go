@@ -0,0 +1,604 @@
+package orm
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// TAG_DEFAULT column with `default:"true"` insert DEFAULT when value is nil or zero
+var TAG_DEFAULT = "default"
+
+// postgres accept maximum 65535 bind parameters per statement
+const maxBindParameters = 65535
+
+type writeColumn struct {
+	name  string // column name from db tag
+	field string // struct field name
+	meta  *fieldMeta
+	isPK  bool
+}
+
+type writeModel struct {
+	table      string
+	columns    []writeColumn
+	pkFields   []string
+	softDelete *fieldMeta
+	createTime *fieldMeta
+	updateTime *fieldMeta
+	version    *fieldMeta
+	audit      bool
+	options    MapperOption // registry of value and RETURNING
+}
+
+type statement struct {
+	query string
+	args  []interface{}
+}
+
+func newWriteModel(model interface{}, options MapperOption) (writeModel, error) {
+	if options.registryErr != nil {
+		return writeModel{}, options.registryErr
+	}
+	if err := validateModel(model); err != nil {
+		return writeModel{}, err
+	}
+	meta, err := getModelMeta(model)
+	if err != nil {
+		return writeModel{}, err
+	}
+	wm := writeModel{
+		table:      meta.table,
+		columns:    make([]writeColumn, 0),
+		pkFields:   make([]string, 0),
+		softDelete: meta.softDelete,
+		createTime: meta.createTime,
+		updateTime: meta.updateTime,
+		version:    meta.version,
+		audit:      meta.audit,
+		options:    options,
+	}
+	if wm.table == "" {
+		return wm, ErrTableNameNotFound
+	}
+
+	for _, pkField := range meta.pkFields {
+		if pkField = strings.TrimSpace(pkField); pkField != "" {
+			wm.pkFields = append(wm.pkFields, pkField)
+		}
+	}
+	if len(wm.pkFields) == 0 {
+		return wm, ErrPrimaryKeyNotFound
+	}
+
+	/* embedded struct is flattened */
+	for _, field := range meta.fields {
+		if field.column == "" {
+			continue
+		}
+		wm.columns = append(wm.columns, writeColumn{
+			name:  field.column,
+			field: field.name,
+			meta:  field,
+			isPK:  inStrings(wm.pkFields, field.name),
+		})
+	}
+	for _, pkField := range wm.pkFields {
+		if _, ok := wm.columnByField(pkField); !ok {
+			return wm, fmt.Errorf("%w: primary key %s must have db tag", ErrFieldNotFound, pkField)
+		}
+	}
+
+	return wm, nil
+}
+
+func (w writeModel) columnByField(field string) (writeColumn, bool) {
+	for _, column := range w.columns {
+		if column.field == field {
+			return column, true
+		}
+	}
+	return writeColumn{}, false
+}
+
+// columnOf column of field meta, false when field is nil
+func (w writeModel) columnOf(field *fieldMeta) (writeColumn, bool) {
+	if field == nil {
+		return writeColumn{}, false
+	}
+	return w.columnByField(field.name)
+}
+
+func (w writeModel) columnByName(name string) (writeColumn, bool) {
+	for _, column := range w.columns {
+		if column.name == name {
+			return column, true
+		}
+	}
+	return writeColumn{}, false
+}
+
+func (w writeModel) pkColumns() []writeColumn {
+	var columns = make([]writeColumn, 0, len(w.pkFields))
+	for _, pkField := range w.pkFields {
+		column, _ := w.columnByField(pkField)
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+func (w writeModel) returning() string {
+	return "RETURNING " + joinColumns(w.columns)
+}
+
+func (w writeModel) insertStatement(models []reflect.Value) (statement, error) {
+	var stmt = statement{args: make([]interface{}, 0, len(models)*len(w.columns))}
+	insert, err := w.insertClause(models, &stmt.args)
+	if err != nil {
+		return stmt, err
+	}
+	stmt.query = fmt.Sprintf(`%s %s`, insert, w.returning())
+	return stmt, nil
+}
+
+/*
+zero value of single primary key, column with default tag
+and field of nil embedded pointer write as DEFAULT
+so database can fill generated id, default timestamp
+other nil value is inserted as NULL
+*/
+func (w writeModel) insertClause(models []reflect.Value, args *[]interface{}) (string, error) {
+	var rows = make([]string, 0, len(models))
+	for _, model := range models {
+		var placeholders = make([]string, 0, len(w.columns))
+		for _, column := range w.columns {
+			val, err := getColumnValue(model, column, w.options)
+			if err != nil {
+				return "", err
+			}
+			if w.isDefault(model, column, val) {
+				placeholders = append(placeholders, "DEFAULT")
+				continue
+			}
+			*args = append(*args, val)
+			placeholders = append(placeholders, fmt.Sprintf("$%d", len(*args)))
+		}
+		rows = append(rows, "("+strings.Join(placeholders, ",")+")")
+	}
+
+	return fmt.Sprintf(
+		`INSERT INTO %s (%s) VALUES %s`,
+		quoteIdentifier(w.table),
+		joinColumns(w.columns),
+		strings.Join(rows, ","),
+	), nil
+}
+
+// isDefault composite key always bind, component may be zero as line_no 0
+func (w writeModel) isDefault(model reflect.Value, column writeColumn, val interface{}) bool {
+	if _, ok := column.meta.lookup(model); !ok {
+		return true
+	}
+	if column.isPK && len(w.pkFields) > 1 {
+		return false
+	}
+	if !column.isPK && !column.meta.dbDefault {
+		return false
+	}
+	return val == nil || reflect.ValueOf(column.meta.value(model)).IsZero()
+}
+
+func (w writeModel) upsertStatement(models []reflect.Value, option UpsertOption) (statement, error) {
+	var stmt = statement{args: make([]interface{}, 0, len(models)*len(w.columns))}
+	insert, err := w.insertClause(models, &stmt.args)
+	if err != nil {
+		return stmt, err
+	}
+	conflictColumns, err := w.conflictColumns(option)
+	if err != nil {
+		return stmt, err
+	}
+
+	var action = "DO NOTHING"
+	if !option.doNothing {
+		updateColumns, err := w.upsertUpdateColumns(option, conflictColumns)
+		if err != nil {
+			return stmt, err
+		}
+		var sets = make([]string, 0, len(updateColumns)+1)
+		for _, column := range updateColumns {
+			if column.meta == w.version {
+				continue
+			}
+			sets = append(sets, fmt.Sprintf("%s = EXCLUDED.%s", quoteIdentifier(column.name), quoteIdentifier(column.name)))
+		}
+		if column, ok := w.columnOf(w.version); ok {
+			sets = append(sets, fmt.Sprintf("%s = %s.%s + 1", quoteIdentifier(column.name), quoteIdentifier(w.table), quoteIdentifier(column.name)))
+		}
+		action = "DO UPDATE SET " + strings.Join(sets, ",")
+		if option.where != "" {
+			action += " WHERE " + shiftPlaceholders(option.where, len(stmt.args))
+			stmt.args = append(stmt.args, option.whereArgs...)
+		}
+	}
+
+	stmt.query = fmt.Sprintf(
+		`%s ON CONFLICT (%s) %s %s`,
+		insert,
+		joinColumns(conflictColumns),
+		action,
+		w.returning(),
+	)
+	return stmt, nil
+}
+
+// conflict target default is pk columns
+func (w writeModel) conflictColumns(option UpsertOption) ([]writeColumn, error) {
+	if len(option.conflictColumns) == 0 {
+		return w.pkColumns(), nil
+	}
+	return w.columnsByName(option.conflictColumns)
+}
+
+/*
+update columns default is every column except pk, conflict target and autoCreateTime,
+version is incremented instead of EXCLUDED value
+*/
+func (w writeModel) upsertUpdateColumns(option UpsertOption, conflictColumns []writeColumn) ([]writeColumn, error) {
+	if len(option.updateColumns) > 0 {
+		return w.columnsByName(option.updateColumns)
+	}
+	var columns = make([]writeColumn, 0, len(w.columns))
+	for _, column := range w.columns {
+		if column.isPK || inColumns(conflictColumns, column) || column.meta == w.createTime {
+			continue
+		}
+		columns = append(columns, column)
+	}
+	if len(columns) == 0 {
+		return nil, ErrNoColumnToUpdate
+	}
+	return columns, nil
+}
+
+func (w writeModel) columnsByName(names []string) ([]writeColumn, error) {
+	var columns = make([]writeColumn, 0, len(names))
+	for _, name := range names {
+		column, ok := w.columnByName(name)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s.%s", ErrColumnNotFound, w.table, name)
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+/*
+keyOf join driver value of columns,
+empty when some value is nil or is written as DEFAULT (key is not known before insert)
+*/
+func (w writeModel) keyOf(model reflect.Value, columns []writeColumn) (string, error) {
+	var vals = make([]string, 0, len(columns))
+	for _, column := range columns {
+		val, err := getColumnValue(model, column, w.options)
+		if err != nil {
+			return "", err
+		}
+		if val == nil || w.isDefault(model, column, val) {
+			return "", nil
+		}
+		vals = append(vals, cast.ToString(val))
+	}
+	return strings.Join(vals, fieldJoinKeyMap), nil
+}
+
+func (w writeModel) updateStatement(model reflect.Value, columns []string) (statement, error) {
+	var stmt = statement{args: make([]interface{}, 0, len(w.columns))}
+
+	var updateColumns = make([]writeColumn, 0, len(w.columns))
+	switch len(columns) {
+	case 0:
+		/* autoCreateTime is kept, same as upsert */
+		for _, column := range w.columns {
+			if !column.isPK && column.meta != w.createTime {
+				updateColumns = append(updateColumns, column)
+			}
+		}
+	default:
+		for _, name := range columns {
+			column, ok := w.columnByName(name)
+			if !ok {
+				return stmt, fmt.Errorf("%w: %s.%s", ErrColumnNotFound, w.table, name)
+			}
+			updateColumns = append(updateColumns, column)
+		}
+		/* autoUpdateTime is always updated */
+		if column, ok := w.columnOf(w.updateTime); ok && !inColumns(updateColumns, column) {
+			updateColumns = append(updateColumns, column)
+		}
+	}
+
+	var sets = make([]string, 0, len(updateColumns)+1)
+	for _, column := range updateColumns {
+		if column.meta == w.version {
+			continue
+		}
+		val, err := getColumnValue(model, column, w.options)
+		if err != nil {
+			return stmt, err
+		}
+		stmt.args = append(stmt.args, val)
+		sets = append(sets, fmt.Sprintf("%s = $%d", quoteIdentifier(column.name), len(stmt.args)))
+	}
+	if len(sets) == 0 {
+		return stmt, ErrNoColumnToUpdate
+	}
+
+	where, err := w.wherePK(model, &stmt.args)
+	if err != nil {
+		return stmt, err
+	}
+	/* optimistic lock, "version" = "version" + 1 WHERE "version" = current version */
+	if column, ok := w.columnOf(w.version); ok {
+		val, err := getColumnValue(model, column, w.options)
+		if err != nil {
+			return stmt, err
+		}
+		stmt.args = append(stmt.args, val)
+		sets = append(sets, fmt.Sprintf("%s = %s + 1", quoteIdentifier(column.name), quoteIdentifier(column.name)))
+		where += fmt.Sprintf(" AND %s = $%d", quoteIdentifier(column.name), len(stmt.args))
+	}
+
+	stmt.query = fmt.Sprintf(
+		`UPDATE %s SET %s WHERE %s %s`,
+		quoteIdentifier(w.table),
+		strings.Join(sets, ","),
+		where,
+		w.returning(),
+	)
+	return stmt, nil
+}
+
+func (w writeModel) deleteStatement(models []reflect.Value) (statement, error) {
+	var stmt = statement{args: make([]interface{}, 0, len(models)*len(w.pkFields))}
+	var tuples = make([]string, 0, len(models))
+	for _, model := range models {
+		tuple, err := w.pkTuple(model, &stmt.args)
+		if err != nil {
+			return stmt, err
+		}
+		tuples = append(tuples, tuple)
+	}
+
+	stmt.query = fmt.Sprintf(
+		`DELETE FROM %s WHERE (%s) IN (%s)`,
+		quoteIdentifier(w.table),
+		joinColumns(w.pkColumns()),
+		strings.Join(tuples, ","),
+	)
+	return stmt, nil
+}
+
+/*
+softDeleteStatement set soft delete column of not deleted rows, autoUpdateTime and version
+are bumped same as updateStatement, version is checked in tuple. example
+UPDATE "orders" SET "deleted_at" = $1,"version" = "version" + 1 WHERE ("id","version") IN (($2,$3)) AND "deleted_at" IS NULL
+*/
+func (w writeModel) softDeleteStatement(models []reflect.Value, deletedValue interface{}, updatedAt interface{}) (statement, error) {
+	var stmt = statement{args: []interface{}{deletedValue}}
+	var sets = []string{fmt.Sprintf("%s = $1", quoteIdentifier(w.softDelete.column))}
+	if column, ok := w.columnOf(w.updateTime); ok {
+		stmt.args = append(stmt.args, updatedAt)
+		sets = append(sets, fmt.Sprintf("%s = $%d", quoteIdentifier(column.name), len(stmt.args)))
+	}
+	var keyColumns = w.pkColumns()
+	version, hasVersion := w.columnOf(w.version)
+	if hasVersion {
+		sets = append(sets, fmt.Sprintf("%s = %s + 1", quoteIdentifier(version.name), quoteIdentifier(version.name)))
+		keyColumns = append(keyColumns, version)
+	}
+
+	var tuples = make([]string, 0, len(models))
+	for _, model := range models {
+		tuple, err := w.pkTuple(model, &stmt.args)
+		if err != nil {
+			return stmt, err
+		}
+		if hasVersion {
+			val, err := getColumnValue(model, version, w.options)
+			if err != nil {
+				return stmt, err
+			}
+			stmt.args = append(stmt.args, val)
+			tuple = fmt.Sprintf("%s,$%d)", strings.TrimSuffix(tuple, ")"), len(stmt.args))
+		}
+		tuples = append(tuples, tuple)
+	}
+
+	stmt.query = fmt.Sprintf(
+		`UPDATE %s SET %s WHERE (%s) IN (%s) AND %s`,
+		quoteIdentifier(w.table),
+		strings.Join(sets, ","),
+		joinColumns(keyColumns),
+		strings.Join(tuples, ","),
+		w.notDeletedCondition(),
+	)
+	return stmt, nil
+}
+
+// notDeletedCondition example "deleted_at" IS NULL
+func (w writeModel) notDeletedCondition() string {
+	if w.softDelete.isBool() {
+		return quoteIdentifier(w.softDelete.column) + " IS NOT TRUE"
+	}
+	return quoteIdentifier(w.softDelete.column) + " IS NULL"
+}
+
+func (w writeModel) wherePK(model reflect.Value, args *[]interface{}) (string, error) {
+	var conditions = make([]string, 0, len(w.pkFields))
+	for _, column := range w.pkColumns() {
+		val, err := w.pkDriverValue(model, column)
+		if err != nil {
+			return "", err
+		}
+		*args = append(*args, val)
+		conditions = append(conditions, fmt.Sprintf("%s = $%d", quoteIdentifier(column.name), len(*args)))
+	}
+	return strings.Join(conditions, " AND "), nil
+}
+
+func (w writeModel) pkTuple(model reflect.Value, args *[]interface{}) (string, error) {
+	var placeholders = make([]string, 0, len(w.pkFields))
+	for _, column := range w.pkColumns() {
+		val, err := w.pkDriverValue(model, column)
+		if err != nil {
+			return "", err
+		}
+		*args = append(*args, val)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(*args)))
+	}
+	return "(" + strings.Join(placeholders, ",") + ")", nil
+}
+
+func (w writeModel) pkDriverValue(model reflect.Value, column writeColumn) (interface{}, error) {
+	val, err := getColumnValue(model, column, w.options)
+	if err != nil {
+		return nil, err
+	}
+	if val == nil {
+		return nil, fmt.Errorf("%w: %s", ErrPrimaryKeyIsEmpty, column.field)
+	}
+	return val, nil
+}
+
+/*
+registry (of options) which implement RegistryValuer encode value before driver.Valuer,
+field of nil embedded pointer is nil
+*/
+func getColumnValue(model reflect.Value, column writeColumn, options MapperOption) (interface{}, error) {
+	val, ok := column.meta.lookup(model)
+	if !ok {
+		/* field of nil embedded pointer */
+		return nil, nil
+	}
+	if registry, err := column.meta.getRegistry(options); err == nil && registry != nil {
+		if valuer, ok := registry.(RegistryValuer); ok {
+			return valuer.DriverValue(val)
+		}
+	}
+	return getDriverValue(val)
+}
+
+/*
+convert value with driver.Valuer of each type
+other value pass through database/sql default converter
+*/
+func getDriverValue(val interface{}) (interface{}, error) {
+	if isNil(val) {
+		return nil, nil
+	}
+	if valuer, ok := val.(driver.Valuer); ok {
+		return valuer.Value()
+	}
+	return val, nil
+}
+
+func quoteIdentifier(name string) string {
+	parts := strings.Split(name, ".")
+	for index := range parts {
+		parts[index] = `"` + strings.ReplaceAll(parts[index], `"`, `""`) + `"`
+	}
+	return strings.Join(parts, ".")
+}
+
+// columnNames raw column name without quote
+func columnNames(columns []writeColumn) []string {
+	var names = make([]string, 0, len(columns))
+	for _, column := range columns {
+		names = append(names, column.name)
+	}
+	return names
+}
+
+func joinColumns(columns []writeColumn) string {
+	var names = make([]string, 0, len(columns))
+	for _, column := range columns {
+		names = append(names, quoteIdentifier(column.name))
+	}
+	return strings.Join(names, ",")
+}
+
+var placeholderRegexp = regexp.MustCompile(`^\$(\d+)`)
+var dollarQuoteRegexp = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
+
+/*
+shiftPlaceholders renumber $1..$n of condition to follow statement args,
+$n inside 'string', "identifier", $tag$ dollar quoted string$tag$ and -- comment is kept as it is
+*/
+func shiftPlaceholders(condition string, offset int) string {
+	var out strings.Builder
+	for index := 0; index < len(condition); {
+		var end int
+		switch ch := condition[index]; {
+		case ch == '\'' || ch == '"':
+			/* quote is escaped by doubling, 'it''s' */
+			end = index + 1
+			for end < len(condition) && !(condition[end] == ch && (end+1 == len(condition) || condition[end+1] != ch)) {
+				if condition[end] == ch {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(condition))
+		case strings.HasPrefix(condition[index:], "--"):
+			end = len(condition)
+			if newline := strings.IndexByte(condition[index:], '\n'); newline >= 0 {
+				end = index + newline + 1
+			}
+		case ch == '$' && (index == 0 || !isIdentifierChar(condition[index-1])):
+			if placeholder := placeholderRegexp.FindString(condition[index:]); placeholder != "" {
+				out.WriteString(fmt.Sprintf("$%d", cast.ToInt(placeholder[1:])+offset))
+				index += len(placeholder)
+				continue
+			}
+			end = index + 1
+			if tag := dollarQuoteRegexp.FindString(condition[index:]); tag != "" {
+				end = len(condition)
+				if closing := strings.Index(condition[index+len(tag):], tag); closing >= 0 {
+					end = index + len(tag) + closing + len(tag)
+				}
+			}
+		default:
+			end = index + 1
+		}
+		out.WriteString(condition[index:end])
+		index = end
+	}
+	return out.String()
+}
+
+func isIdentifierChar(ch byte) bool {
+	return ch == '_' || ch == '$' || (ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+func inColumns(columns []writeColumn, target writeColumn) bool {
+	for _, column := range columns {
+		if column.name == target.name {
+			return true
+		}
+	}
+	return false
+}
+
+func inStrings(list []string, val string) bool {
+	for _, item := range list {
+		if item == val {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+package orm
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+/*
+column group of database type name,
+empty group is unknown type and is not checked
+*/
+const (
+	columnGroupInteger = "integer"
+	columnGroupFloat   = "float"
+	columnGroupNumeric = "numeric"
+	columnGroupText    = "text"
+	columnGroupUUID    = "uuid"
+	columnGroupBool    = "bool"
+	columnGroupTime    = "time"
+	columnGroupJSON    = "json"
+	columnGroupBytes   = "bytes"
+	columnGroupArray   = "array"
+)
+
+var columnGroups = map[string]string{
+	"INT2": columnGroupInteger, "INT4": columnGroupInteger, "INT8": columnGroupInteger,
+	"SMALLINT": columnGroupInteger, "INT": columnGroupInteger, "INTEGER": columnGroupInteger,
+	"BIGINT": columnGroupInteger, "TINYINT": columnGroupInteger, "MEDIUMINT": columnGroupInteger,
+	"SERIAL": columnGroupInteger, "BIGSERIAL": columnGroupInteger, "OID": columnGroupInteger,
+	"FLOAT4": columnGroupFloat, "FLOAT8": columnGroupFloat, "REAL": columnGroupFloat,
+	"FLOAT": columnGroupFloat, "DOUBLE": columnGroupFloat, "DOUBLE PRECISION": columnGroupFloat,
+	"NUMERIC": columnGroupNumeric, "DECIMAL": columnGroupNumeric, "MONEY": columnGroupNumeric,
+	"TEXT": columnGroupText, "VARCHAR": columnGroupText, "BPCHAR": columnGroupText, "CHAR": columnGroupText,
+	"NAME": columnGroupText, "CITEXT": columnGroupText, "CHARACTER VARYING": columnGroupText,
+	"UUID": columnGroupUUID,
+	"BOOL": columnGroupBool, "BOOLEAN": columnGroupBool,
+	"DATE": columnGroupTime, "TIME": columnGroupTime, "TIMETZ": columnGroupTime,
+	"TIMESTAMP": columnGroupTime, "TIMESTAMPTZ": columnGroupTime, "DATETIME": columnGroupTime,
+	"JSON": columnGroupJSON, "JSONB": columnGroupJSON,
+	"BYTEA": columnGroupBytes, "BLOB": columnGroupBytes,
+}
+
+var (
+	integerGroups = []string{columnGroupInteger, columnGroupNumeric}
+	floatGroups   = []string{columnGroupInteger, columnGroupFloat, columnGroupNumeric}
+	uuidGroups    = []string{columnGroupUUID, columnGroupText}
+	timeGroups    = []string{columnGroupTime}
+	boolGroups    = []string{columnGroupBool}
+	arrayGroups   = []string{columnGroupArray}
+)
+
+/*
+registryColumnGroups is accepted column group of builtin registry by TypeName,
+registry which is not in this map and not implement RegistryColumnType is not checked
+string and []byte accept every column
+*/
+var registryColumnGroups = map[string][]string{
+	"uuid": uuidGroups, "guid": uuidGroups, "zerouuid": uuidGroups,
+	"int": integerGroups, "int8": integerGroups, "int16": integerGroups, "int32": integerGroups, "int64": integerGroups,
+	"uint": integerGroups, "uint8": integerGroups, "uint16": integerGroups, "uint32": integerGroups, "uint64": integerGroups,
+	"zeroint": integerGroups,
+	"float32": floatGroups, "float64": floatGroups, "zerofloat": floatGroups,
+	"decimal":   {columnGroupInteger, columnGroupFloat, columnGroupNumeric, columnGroupText},
+	"bool":      boolGroups,
+	"zerobool":  boolGroups,
+	"timestamp": timeGroups, "date": timeGroups, "time.Time": timeGroups,
+	"json":     {columnGroupJSON, columnGroupText, columnGroupBytes},
+	"jsonb":    {columnGroupJSON, columnGroupText, columnGroupBytes},
+	"[]string": arrayGroups, "[]int": arrayGroups, "[]int32": arrayGroups, "[]int64": arrayGroups,
+	"[]float32": arrayGroups, "[]float64": arrayGroups, "[]bool": arrayGroups, "[]uuid": arrayGroups,
+}
+
+func getColumnGroup(databaseTypeName string) string {
+	name := strings.ToUpper(strings.TrimSpace(databaseTypeName))
+	if strings.HasPrefix(name, "_") || strings.HasSuffix(name, "[]") {
+		return columnGroupArray
+	}
+	return columnGroups[name]
+}
+
+// acceptColumnType return true when registry is unknown or column type is unknown
+func acceptColumnType(registry Registry, databaseTypeName string) bool {
+	if databaseTypeName == "" {
+		return true
+	}
+	if checker, ok := registry.(RegistryColumnType); ok {
+		return checker.AcceptColumnType(databaseTypeName)
+	}
+	groups, ok := registryColumnGroups[registry.TypeName()]
+	if !ok {
+		return true
+	}
+	group := getColumnGroup(databaseTypeName)
+	if group == "" {
+		return true
+	}
+	for _, accept := range groups {
+		if accept == group {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+checkStrict compare columns of rows with plan of every model,
+column must map into some field (except total_row and root_rank), every db field of model
+which receive some column (and main model) must be mapped and column type must be compatible
+*/
+func checkStrict(ms []modelStruct, columns []*sql.ColumnType, options MapperOption) []*MappingError {
+	var root *modelStruct
+	var models = make([]*modelStruct, 0, len(ms))
+	var addModel func(m *modelStruct)
+	addModel = func(m *modelStruct) {
+		for _, exists := range models {
+			if exists.modelType == m.modelType && exists.alias == m.alias {
+				return
+			}
+		}
+		models = append(models, m)
+		for index := range m.subRefModel {
+			addModel(&m.subRefModel[index])
+		}
+	}
+	for index := range ms {
+		if ms[index].IsMainModel() {
+			root = &ms[index]
+		} else if !options.autobinding {
+			continue
+		}
+		addModel(&ms[index])
+	}
+
+	var issues = make([]*MappingError, 0)
+	var mapped = make([]bool, len(columns))
+	for _, m := range models {
+		var received = make(map[string]bool, len(m.plan))
+		for index, field := range m.plan {
+			if field == nil {
+				continue
+			}
+			mapped[index] = true
+			received[field.name] = true
+
+			registry, err := field.getRegistry(options)
+			if err != nil || registry == nil {
+				continue
+			}
+			if dbType := columns[index].DatabaseTypeName(); !acceptColumnType(registry, dbType) {
+				issues = append(issues, &MappingError{
+					Model:  m.meta.modelType.String(),
+					Field:  field.name,
+					Column: columns[index].Name(),
+					Row:    -1,
+					Err:    fmt.Errorf("%w: %s into %s", ErrIncompatibleColumn, dbType, registry.TypeName()),
+				})
+			}
+		}
+		if len(received) == 0 && m != root {
+			/* model is not selected by query */
+			continue
+		}
+		for _, field := range m.meta.fields {
+			if field.column == "" || field.typeTag == "-" || received[field.name] {
+				continue
+			}
+			issues = append(issues, &MappingError{
+				Model:  m.meta.modelType.String(),
+				Field:  field.name,
+				Column: field.column,
+				Row:    -1,
+				Err:    ErrUnmappedField,
+			})
+		}
+	}
+	for index, col := range columns {
+		if mapped[index] || strings.EqualFold(col.Name(), PAGINATE_COLUMN_NAME) || strings.EqualFold(col.Name(), PAGINATE_RANK_COLUMN_NAME) {
+			continue
+		}
+		issues = append(issues, &MappingError{Column: col.Name(), Row: -1, Err: ErrUnmappedColumn})
+	}
+
+	return issues
+}
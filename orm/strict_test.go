@@ -0,0 +1,124 @@
+package orm_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/Pheethy/psql/orm"
+	"github.com/Pheethy/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+/* typedConnector is fake driver which report DatabaseTypeName of column, sqlmock can not */
+type typedConnector struct {
+	columns []string
+	types   []string
+	values  [][]driver.Value
+}
+
+type typedConn struct{ typedConnector }
+
+type typedRows struct {
+	typedConnector
+	index int
+}
+
+func (c typedConnector) Connect(context.Context) (driver.Conn, error) { return typedConn{c}, nil }
+func (c typedConnector) Driver() driver.Driver                        { return nil }
+
+func (c typedConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+func (c typedConn) Close() error              { return nil }
+func (c typedConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+func (c typedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return &typedRows{typedConnector: c.typedConnector}, nil
+}
+
+func (r *typedRows) Columns() []string                           { return r.columns }
+func (r *typedRows) Close() error                                { return nil }
+func (r *typedRows) ColumnTypeDatabaseTypeName(index int) string { return r.types[index] }
+func (r *typedRows) Next(dest []driver.Value) error {
+	if r.index >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.index])
+	r.index++
+	return nil
+}
+
+func TestStrictMode(t *testing.T) {
+	var newRows = func(t *testing.T, connector typedConnector) *sqlx.Rows {
+		db := sql.OpenDB(connector)
+		t.Cleanup(func() { db.Close() })
+		rows, err := sqlx.NewDb(db, "postgres").Queryx(`SELECT * FROM menus`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { rows.Close() })
+		return rows
+	}
+	var menuConnector = func() typedConnector {
+		return typedConnector{
+			columns: []string{"menus.id", "menus.name", "menu_items.id", "menu_items.type", "menu_items.price", "menu_items.menu_id", "total_row"},
+			types:   []string{"INT4", "TEXT", "INT4", "VARCHAR", "NUMERIC", "INT4", "INT8"},
+			values: [][]driver.Value{
+				{int64(1), "breakfast", int64(11), "drink", "40.50", int64(1), int64(1)},
+			},
+		}
+	}
+
+	t.Run("success_every_column_mapped", func(t *testing.T) {
+		mapper, err := orm.Orm(new(Menu), newRows(t, menuConnector()), orm.NewMapperOption().SetStrict())
+		assert.NoError(t, err)
+		menus := mapper.GetData().([]*Menu)
+		assert.Len(t, menus, 1)
+		assert.Len(t, menus[0].Items, 1)
+	})
+
+	t.Run("success_relation_not_selected", func(t *testing.T) {
+		connector := typedConnector{
+			columns: []string{"menus.id", "menus.name"},
+			types:   []string{"INT4", "TEXT"},
+			values:  [][]driver.Value{{int64(1), "breakfast"}},
+		}
+		_, err := orm.Orm(new(Menu), newRows(t, connector), orm.NewMapperOption().SetStrict())
+		assert.NoError(t, err)
+	})
+
+	t.Run("error_unmapped_column_and_field", func(t *testing.T) {
+		connector := menuConnector()
+		connector.columns[1] = "menus.nmae"
+		_, err := orm.Orm(new(Menu), newRows(t, connector), orm.NewMapperOption().SetStrict())
+		assert.ErrorIs(t, err, orm.ErrUnmappedColumn)
+		assert.ErrorIs(t, err, orm.ErrUnmappedField)
+		assert.Contains(t, err.Error(), `column "menus.nmae"`)
+		assert.Contains(t, err.Error(), `field orm_test.Menu.Name, column "name"`)
+	})
+
+	t.Run("error_incompatible_column_type", func(t *testing.T) {
+		connector := menuConnector()
+		connector.types[0] = "TEXT"
+		_, err := orm.Orm(new(Menu), newRows(t, connector), orm.NewMapperOption().SetStrict())
+		var mappingErr *orm.MappingError
+		assert.True(t, errors.As(err, &mappingErr))
+		assert.ErrorIs(t, err, orm.ErrIncompatibleColumn)
+		assert.Equal(t, "ID", mappingErr.Field)
+		assert.Equal(t, "menus.id", mappingErr.Column)
+	})
+
+	t.Run("success_warning_mode_collect_issue", func(t *testing.T) {
+		connector := menuConnector()
+		connector.columns[1] = "menus.nmae"
+		mapper, err := orm.Orm(new(Menu), newRows(t, connector), orm.NewMapperOption().SetStrictWarning())
+		assert.NoError(t, err)
+		assert.Len(t, mapper.GetWarnings(), 2)
+		menus := mapper.GetData().([]*Menu)
+		assert.Len(t, menus, 1)
+		assert.Equal(t, "", menus[0].Name)
+	})
+}
@@ -0,0 +1,179 @@
+package orm
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/Pheethy/sqlx"
+)
+
+/*
+Tracker keep snapshot of column value per model pointer,
+value is driver value same as written by Insert/Update (registry valuer, driver.Valuer)
+*/
+type Tracker struct {
+	mu        sync.Mutex
+	snapshots map[interface{}]map[string]interface{} // model pointer -> column -> value
+	options   MapperOption                           // registry of value
+}
+
+// Change is column which value is different from snapshot
+type Change struct {
+	Field  string      `json:"field"`
+	Column string      `json:"column"`
+	Old    interface{} `json:"old"`
+	New    interface{} `json:"new"`
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{snapshots: make(map[interface{}]map[string]interface{}), options: NewMapperOption()}
+}
+
+// Track snapshot current value of models (model pointer or slice of model pointer), replace old snapshot
+func (t *Tracker) Track(models ...interface{}) error {
+	for _, model := range models {
+		if reflect.ValueOf(model).Kind() != reflect.Slice {
+			model = []interface{}{model}
+		}
+		elems, err := getModelValues(model)
+		if err != nil {
+			return err
+		}
+		for _, elem := range elems {
+			snapshot, err := takeSnapshot(elem, t.options)
+			if err != nil {
+				return err
+			}
+			t.mu.Lock()
+			t.snapshots[elem.Interface()] = snapshot
+			t.mu.Unlock()
+		}
+	}
+	return nil
+}
+
+// Forget remove snapshot of model
+func (t *Tracker) Forget(model interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.snapshots, model)
+}
+
+/*
+Changes of model since snapshot in db column order,
+return ErrModelNotTracked when model is not loaded with SetTrackChanges or Track
+*/
+func (t *Tracker) Changes(model interface{}) ([]Change, error) {
+	if t == nil {
+		return nil, ErrModelNotTracked
+	}
+	t.mu.Lock()
+	snapshot, ok := t.snapshots[model]
+	t.mu.Unlock()
+	if !ok {
+		return nil, ErrModelNotTracked
+	}
+	meta, err := getModelMeta(model)
+	if err != nil {
+		return nil, err
+	}
+	current, err := takeSnapshot(reflect.ValueOf(model), t.options)
+	if err != nil {
+		return nil, err
+	}
+	return diffSnapshot(meta, snapshot, current), nil
+}
+
+// diffSnapshot changed column of model in db column order
+func diffSnapshot(meta *modelMeta, old map[string]interface{}, new map[string]interface{}) []Change {
+	var changes = make([]Change, 0)
+	for _, field := range meta.fields {
+		if field.column == "" {
+			continue
+		}
+		if !reflect.DeepEqual(old[field.column], new[field.column]) {
+			changes = append(changes, Change{Field: field.name, Column: field.column, Old: old[field.column], New: new[field.column]})
+		}
+	}
+	return changes
+}
+
+// IsChanged model has some changed column since snapshot
+func (t *Tracker) IsChanged(model interface{}) (bool, error) {
+	changes, err := t.Changes(model)
+	return len(changes) > 0, err
+}
+
+func takeSnapshot(elem reflect.Value, options MapperOption) (map[string]interface{}, error) {
+	meta, err := getModelMeta(elem.Type())
+	if err != nil {
+		return nil, err
+	}
+	var snapshot = make(map[string]interface{}, len(meta.columnMap))
+	for _, field := range meta.fields {
+		if field.column == "" {
+			continue
+		}
+		val, err := getColumnValue(elem, writeColumn{name: field.column, field: field.name, meta: field}, options)
+		if err != nil {
+			return nil, &MappingError{Model: meta.modelType.String(), Field: field.name, Column: field.column, Row: -1, Err: err}
+		}
+		snapshot[field.column] = val
+	}
+	return snapshot, nil
+}
+
+// trackModels snapshot every model of mapped slice include bound relation
+func (t *Tracker) trackModels(slice reflect.Value) error {
+	return walkModels(slice, func(elem reflect.Value, index int) error {
+		snapshot, err := takeSnapshot(elem, t.options)
+		if err != nil {
+			return err
+		}
+		t.mu.Lock()
+		t.snapshots[elem.Interface()] = snapshot
+		t.mu.Unlock()
+		return nil
+	})
+}
+
+/*
+UpdateChanged update only changed column of tracked model by primary key,
+model without change is skipped. snapshot is refreshed after update
+*/
+func UpdateChanged(ctx context.Context, exec sqlx.ExtContext, tracker *Tracker, model interface{}) error {
+	return UpdateChangedBatch(ctx, exec, tracker, []interface{}{model})
+}
+
+// UpdateChangedBatch execute one UPDATE statement per changed model
+func UpdateChangedBatch(ctx context.Context, exec sqlx.ExtContext, tracker *Tracker, models interface{}) error {
+	if tracker != nil {
+		/* registry of value is the same as snapshot */
+		ctx = WithMapperOption(ctx, tracker.options)
+	}
+	var changedColumns = func(wm writeModel, elem reflect.Value) ([]string, bool, error) {
+		changes, err := tracker.Changes(elem.Interface())
+		if err != nil {
+			return nil, false, err
+		}
+		/* pk is WHERE, autoUpdateTime and version is set by Update */
+		var columns = make([]string, 0, len(changes))
+		for _, change := range changes {
+			column, _ := wm.columnByName(change.Column)
+			if column.isPK || column.meta == wm.updateTime || column.meta == wm.version {
+				continue
+			}
+			columns = append(columns, change.Column)
+		}
+		return columns, len(columns) > 0, nil
+	}
+	err := updateBatch(ctx, exec, models, func(wm writeModel, elem reflect.Value) (bool, error) {
+		_, ok, err := changedColumns(wm, elem)
+		return ok, err
+	}, changedColumns)
+	if err != nil {
+		return err
+	}
+	return tracker.Track(models)
+}
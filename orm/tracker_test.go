@@ -0,0 +1,113 @@
+package orm_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/Pheethy/psql/orm"
+	"github.com/Pheethy/sqlx"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v2"
+)
+
+type Task struct {
+	TableName struct{} `json:"-" db:"tasks" pk:"ID"`
+	ID        int      `json:"id" db:"id"`
+	Title     string   `json:"title" db:"title"`
+	Status    int      `json:"status" db:"status"`
+	Steps     []*Step  `json:"steps" db:"-" fk:"fk_field1:ID,fk_field2:TaskID"`
+}
+
+type Step struct {
+	TableName struct{} `json:"-" db:"steps" pk:"ID"`
+	ID        int      `json:"id" db:"id"`
+	TaskID    int      `json:"task_id" db:"task_id"`
+	Done      bool     `json:"done" db:"done"`
+}
+
+func TestTracker(t *testing.T) {
+	var mapTasks = func(t *testing.T, db *sqlx.DB, dbmock sqlmock.Sqlmock, options orm.MapperOption) orm.Mapper {
+		dbmock.ExpectQuery(`SELECT (.+) tasks`).WillReturnRows(sqlmock.NewRows([]string{"tasks.id", "tasks.title", "tasks.status", "steps.id", "steps.task_id", "steps.done"}).
+			AddRow(1, "Write", 1, 10, 1, false).
+			AddRow(1, "Write", 1, 11, 1, true).
+			AddRow(2, "Review", 0, nil, nil, nil),
+		)
+		rows, err := db.Queryx(`SELECT * FROM tasks`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		mapper, err := orm.Orm(new(Task), rows, options)
+		assert.NoError(t, err)
+		return mapper
+	}
+
+	t.Run("success_changes_of_root_and_relation", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		mapper := mapTasks(t, db, dbmock, orm.NewMapperOption().SetTrackChanges())
+		tasks := mapper.GetData().([]*Task)
+		tracker := mapper.GetTracker()
+
+		tasks[0].Title = "Write test"
+		tasks[0].Steps[0].Done = true
+		changes, err := tracker.Changes(tasks[0])
+		assert.NoError(t, err)
+		assert.Equal(t, []orm.Change{{Field: "Title", Column: "title", Old: "Write", New: "Write test"}}, changes)
+
+		changes, err = tracker.Changes(tasks[0].Steps[0])
+		assert.NoError(t, err)
+		assert.Equal(t, []orm.Change{{Field: "Done", Column: "done", Old: false, New: true}}, changes)
+
+		changed, err := tracker.IsChanged(tasks[1])
+		assert.NoError(t, err)
+		assert.False(t, changed)
+	})
+
+	t.Run("success_update_only_changed_column", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		mapper := mapTasks(t, db, dbmock, orm.NewMapperOption().SetTrackChanges())
+		tasks := mapper.GetData().([]*Task)
+		tracker := mapper.GetTracker()
+
+		tasks[0].Status = 2
+		sql := `UPDATE "tasks" SET "status" = $1 WHERE "id" = $2 RETURNING "id","title","status"`
+		dbmock.ExpectQuery(regexp.QuoteMeta(sql)).
+			WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status"}).AddRow(1, "Write", 2))
+
+		/* task 2 is not changed, no statement */
+		err := orm.UpdateChangedBatch(context.Background(), db, tracker, tasks)
+		assert.NoError(t, err)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+
+		changed, err := tracker.IsChanged(tasks[0])
+		assert.NoError(t, err)
+		assert.False(t, changed)
+	})
+
+	t.Run("error_model_not_tracked", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		mapper := mapTasks(t, db, dbmock, orm.NewMapperOption())
+		assert.Nil(t, mapper.GetTracker())
+
+		_, err := mapper.GetTracker().Changes(mapper.GetData().([]*Task)[0])
+		assert.ErrorIs(t, err, orm.ErrModelNotTracked)
+
+		tracker := orm.NewTracker()
+		err = orm.UpdateChanged(context.Background(), db, tracker, &Task{ID: 3, Title: "New"})
+		assert.ErrorIs(t, err, orm.ErrModelNotTracked)
+	})
+
+	t.Run("success_track_inserted_model", func(t *testing.T) {
+		tracker := orm.NewTracker()
+		task := &Task{ID: 3, Title: "New"}
+		assert.NoError(t, tracker.Track(task))
+
+		task.Title = "Renamed"
+		changes, err := tracker.Changes(task)
+		assert.NoError(t, err)
+		assert.Len(t, changes, 1)
+		assert.Equal(t, "title", changes[0].Column)
+	})
+}
@@ -0,0 +1,213 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Pheethy/sqlx"
+)
+
+type UpsertOption struct {
+	conflictColumns []string // conflict target, default pk columns
+	updateColumns   []string // default every column except pk and conflict target
+	doNothing       bool
+	where           string
+	whereArgs       []interface{}
+}
+
+func NewUpsertOption() UpsertOption {
+	return UpsertOption{
+		conflictColumns: make([]string, 0),
+		updateColumns:   make([]string, 0),
+		whereArgs:       make([]interface{}, 0),
+	}
+}
+
+// db column name for ON CONFLICT (...) example "code", "branch_id"
+func (u UpsertOption) SetConflictColumns(columns ...string) UpsertOption {
+	u.conflictColumns = columns
+	return u
+}
+
+// db column name to SET col = EXCLUDED.col on conflict
+func (u UpsertOption) SetUpdateColumns(columns ...string) UpsertOption {
+	u.updateColumns = columns
+	return u
+}
+
+// ON CONFLICT DO NOTHING, conflict rows are not RETURNING back into model
+func (u UpsertOption) SetDoNothing() UpsertOption {
+	u.doNothing = true
+	return u
+}
+
+/*
+condition of DO UPDATE, placeholder start with $1 and is renumbered after VALUES args,
+$n inside string literal, quoted identifier and dollar quoted string is not placeholder |
+example SetWhere(`"products"."updated_at" < EXCLUDED."updated_at" AND "products"."lock" = $1`, false)
+*/
+func (u UpsertOption) SetWhere(condition string, args ...interface{}) UpsertOption {
+	u.where = condition
+	u.whereArgs = args
+	return u
+}
+
+// Upsert INSERT ... ON CONFLICT ... and RETURNING back into model
+func Upsert(ctx context.Context, exec sqlx.ExtContext, model interface{}, option UpsertOption) error {
+	return UpsertBatch(ctx, exec, []interface{}{model}, option)
+}
+
+/*
+UpsertBatch upsert slice of model pointer with multi rows VALUES.
+when some row is skipped by DO NOTHING or WHERE,
+RETURNING rows are mapped back into model by conflict target value and AfterInsert is called only with them.
+return ErrDuplicateConflict when models have the same conflict target value (except DO NOTHING)
+*/
+func UpsertBatch(ctx context.Context, exec sqlx.ExtContext, models interface{}, option UpsertOption) error {
+	elems, err := getModelValues(models)
+	if err != nil || len(elems) == 0 {
+		return err
+	}
+	wm, err := newWriteModel(elems[0].Interface(), mapperOptionFrom(ctx))
+	if err != nil {
+		return err
+	}
+	conflictColumns, err := wm.conflictColumns(option)
+	if err != nil {
+		return err
+	}
+	/* upsert is INSERT statement, use insert hook */
+	if err := callHooks(ctx, hookBeforeInsert, elems); err != nil {
+		return err
+	}
+	if err := wm.stampInsert(elems); err != nil {
+		return err
+	}
+	if !option.doNothing {
+		/* DO UPDATE can not affect the same row twice in one statement */
+		if err := wm.checkConflictKeys(elems, conflictColumns); err != nil {
+			return err
+		}
+	}
+	var written = make([]reflect.Value, 0, len(elems))
+
+	err = wm.auditTx(ctx, exec, func(exec sqlx.ExtContext) error {
+		chunkSize := (maxBindParameters - len(option.whereArgs)) / len(wm.columns)
+		for start := 0; start < len(elems); start += chunkSize {
+			end := start + chunkSize
+			if end > len(elems) {
+				end = len(elems)
+			}
+			stmt, err := wm.upsertStatement(elems[start:end], option)
+			if err != nil {
+				return err
+			}
+			/* old row is found by conflict target, not found is insert */
+			olds, err := wm.auditSelect(ctx, exec, elems[start:end], conflictColumns, "")
+			if err != nil {
+				return err
+			}
+			returning, err := queryUpsertReturning(ctx, exec, stmt, wm, elems[start:end], conflictColumns)
+			if err != nil {
+				return err
+			}
+			if err := wm.auditWrite(ctx, exec, returning, conflictColumns, olds); err != nil {
+				return err
+			}
+			written = append(written, returning...)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	/* row skipped by DO NOTHING or WHERE is not inserted */
+	return callHooks(ctx, hookAfterInsert, written)
+}
+
+// checkConflictKeys conflict target value must be unique in batch, new row with nil or DEFAULT key is skipped
+func (w writeModel) checkConflictKeys(elems []reflect.Value, conflictColumns []writeColumn) error {
+	var rows = make(map[string]int, len(elems))
+	for row, elem := range elems {
+		key, err := w.keyOf(elem, conflictColumns)
+		if err != nil {
+			return err
+		}
+		if key == "" {
+			continue
+		}
+		if exists, ok := rows[key]; ok {
+			return &MappingError{Model: elem.Type().Elem().String(), Column: strings.Join(columnNames(conflictColumns), ","), Row: row, Value: key, Err: fmt.Errorf("%w: same as row %d", ErrDuplicateConflict, exists)}
+		}
+		rows[key] = row
+	}
+	return nil
+}
+
+// queryUpsertReturning return models which row is returning (inserted or updated)
+func queryUpsertReturning(ctx context.Context, exec sqlx.ExtContext, stmt statement, wm writeModel, models []reflect.Value, conflictColumns []writeColumn) ([]reflect.Value, error) {
+	rows, err := exec.QueryxContext(ctx, stmt.query, stmt.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	var results = make([][]interface{}, 0, len(models))
+	for rows.Next() {
+		values, err := rows.SliceScan()
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	/* row is matched by conflict target value, new row with DEFAULT key follow VALUES order when every row is returning */
+	/* postgres return multi rows INSERT in VALUES order, but it is not documented guarantee */
+	var written = make([]reflect.Value, 0, len(results))
+	var modelByKey = make(map[string]reflect.Value, len(models))
+	var newModels = make([]reflect.Value, 0)
+	for _, model := range models {
+		key, err := wm.keyOf(model, conflictColumns)
+		if err != nil {
+			return nil, err
+		}
+		if key == "" {
+			newModels = append(newModels, model)
+			continue
+		}
+		modelByKey[key] = model
+	}
+	for row, values := range results {
+		returning := reflect.New(models[0].Type().Elem())
+		if err := fillValue(returning.Interface(), columns, values, row, wm.options); err != nil {
+			return nil, err
+		}
+		key, err := wm.keyOf(returning, conflictColumns)
+		if err != nil {
+			return nil, err
+		}
+		model, ok := modelByKey[key]
+		if ok {
+			delete(modelByKey, key)
+		} else if len(results) == len(models) && len(newModels) > 0 {
+			model, newModels, ok = newModels[0], newModels[1:], true
+		}
+		if !ok {
+			continue
+		}
+		if err := fillValue(model.Interface(), columns, values, row, wm.options); err != nil {
+			return nil, err
+		}
+		written = append(written, model)
+	}
+	return written, nil
+}
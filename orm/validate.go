@@ -0,0 +1,229 @@
+package orm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+/*
+ValidateModels check tag of every model and related model (fk) at startup,
+TableName db and pk tag, db column, type registry and fk field of both side.
+return all problem as one error (errors.Join of *MappingError), nil when valid
+example orm.ValidateModels(new(Order), new(Chef))
+*/
+func ValidateModels(models ...interface{}) error {
+	return ValidateModelsWithOption(NewMapperOption(), models...)
+}
+
+// ValidateModelsWithOption same as ValidateModels with registry from MapperOption.SetRegistry
+func ValidateModelsWithOption(options MapperOption, models ...interface{}) error {
+	var visited = make(map[reflect.Type]bool)
+	var issues = make([]error, 0)
+	for _, model := range models {
+		if isNil(model) {
+			issues = append(issues, &MappingError{Row: -1, Err: ErrMustNotNil})
+			continue
+		}
+		issues = append(issues, validateModelType(reflect.TypeOf(model), visited, options)...)
+	}
+	return errors.Join(issues...)
+}
+
+func validateModelType(modelType reflect.Type, visited map[reflect.Type]bool, options MapperOption) []error {
+	for modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+	if visited[modelType] {
+		return nil
+	}
+	visited[modelType] = true
+
+	var model = modelType.String()
+	var issues = make([]error, 0)
+	var addIssue = func(field string, column string, err error) {
+		issues = append(issues, &MappingError{Model: model, Field: field, Column: column, Row: -1, Err: err})
+	}
+	if modelType.Kind() != reflect.Struct {
+		addIssue("", "", ErrMustBeStruct)
+		return issues
+	}
+
+	/* TableName db and pk */
+	tableField, ok := modelType.FieldByName(TABLE_FIELD_NAME)
+	if !ok || strings.TrimSpace(tableField.Tag.Get(TAGNAME)) == "" {
+		addIssue(TABLE_FIELD_NAME, "", ErrTableNameNotFound)
+	}
+	pkTag := ""
+	if ok {
+		pkTag = strings.TrimSpace(tableField.Tag.Get(TAG_PK))
+	}
+	/* embedded struct is flattened */
+	var modelFields = getModelFields(modelType)
+	if pkTag == "" {
+		addIssue(TABLE_FIELD_NAME, "", ErrPrimaryKeyNotFound)
+	} else {
+		/* primary key must be mapped column, same as soft delete field */
+		var fieldColumns = make(map[string]string, len(modelFields))
+		for _, modelField := range modelFields {
+			fieldColumns[modelField.Name] = modelField.column
+		}
+		for _, pkField := range strings.Split(pkTag, fieldSeperate) {
+			pkField = strings.TrimSpace(pkField)
+			if _, ok := modelType.FieldByName(pkField); !ok {
+				addIssue(pkField, "", fmt.Errorf("%w: primary key", ErrFieldNotFound))
+			} else if fieldColumns[pkField] == "" {
+				addIssue(pkField, "", fmt.Errorf("%w: primary key field must have db tag", ErrColumnNotFound))
+			}
+		}
+	}
+
+	var columns = make(map[string]string)
+	var aliases = make(map[string]string) // fk alias -> field
+	var softDelete string
+	var specialFields = make(map[string]string) // autoCreateTime, autoUpdateTime, version tag -> field
+	for _, modelField := range modelFields {
+		field := modelField.StructField
+		if field.Name == TABLE_FIELD_NAME {
+			continue
+		}
+
+		/* relation */
+		if fkTag := strings.TrimSpace(field.Tag.Get(TAG_FK)); fkTag != "" && !field.Anonymous {
+			/* alias is column prefix, must be unique in model */
+			if alias := newForeignKeyFromTag(fkTag).alias; alias != "" {
+				if exists, ok := aliases[alias]; ok {
+					addIssue(field.Name, "", fmt.Errorf("%w: %q of %s", ErrDuplicateAlias, alias, exists))
+				}
+				aliases[alias] = field.Name
+			}
+			issues = append(issues, validateRelation(modelType, field, fkTag, visited, options)...)
+			continue
+		}
+
+		/* column and registry */
+		column := modelField.column
+		if isSoftDeleteField(field) {
+			if column == "" {
+				addIssue(field.Name, "", fmt.Errorf("%w: field must have db tag", ErrInvalidSoftDelete))
+			} else if softDelete != "" {
+				addIssue(field.Name, column, fmt.Errorf("%w: model already has soft delete field %s", ErrInvalidSoftDelete, softDelete))
+			}
+			softDelete = field.Name
+		}
+		for _, tag := range []string{TAG_AUTO_CREATE_TIME, TAG_AUTO_UPDATE_TIME, TAG_VERSION} {
+			if !isTagEnabled(field, tag) {
+				continue
+			}
+			var errInvalid, isValidType = ErrInvalidAutoTime, isAutoTimeType(field.Type)
+			if tag == TAG_VERSION {
+				errInvalid, isValidType = ErrInvalidVersion, isVersionType(field.Type)
+			}
+			switch exists, ok := specialFields[tag]; {
+			case column == "":
+				addIssue(field.Name, "", fmt.Errorf("%w: field must have db tag", errInvalid))
+			case !isValidType:
+				addIssue(field.Name, column, fmt.Errorf("%w: unsupported type %s", errInvalid, field.Type))
+			case ok:
+				addIssue(field.Name, column, fmt.Errorf("%w: model already has %s field %s", errInvalid, tag, exists))
+			}
+			specialFields[tag] = field.Name
+		}
+		if column == "" {
+			continue
+		}
+		if exists, ok := columns[column]; ok {
+			addIssue(field.Name, column, fmt.Errorf("%w: same column as %s", ErrDuplicateColumn, exists))
+		}
+		columns[column] = field.Name
+		if _, err := getRegistryOfType(field, options); err != nil {
+			addIssue(field.Name, column, err)
+		}
+	}
+	return issues
+}
+
+func validateRelation(modelType reflect.Type, field reflect.StructField, fkTag string, visited map[reflect.Type]bool, options MapperOption) []error {
+	var issues = make([]error, 0)
+	var addIssue = func(err error) {
+		issues = append(issues, &MappingError{Model: modelType.String(), Field: field.Name, Row: -1, Err: err})
+	}
+
+	/* *Model or []*Model */
+	refType := field.Type
+	if refType.Kind() == reflect.Slice {
+		refType = refType.Elem()
+	}
+	if refType.Kind() != reflect.Ptr || refType.Elem().Kind() != reflect.Struct {
+		addIssue(fmt.Errorf("%w: relation must be *Model or []*Model, got %s", ErrMustBeStruct, field.Type))
+		return issues
+	}
+	refType = refType.Elem()
+
+	fk := newForeignKeyFromTag(fkTag)
+	if err := fk.Validate(); err != nil {
+		addIssue(fmt.Errorf("%w: %q", err, fkTag))
+	} else {
+		for index := range fk.fkField1 {
+			parentField, parentOK := modelType.FieldByName(fk.fkField1[index])
+			if !parentOK {
+				addIssue(fmt.Errorf("%w: fk_field1 %s", ErrFieldNotFound, fk.fkField1[index]))
+			}
+			childField, childOK := refType.FieldByName(fk.fkField2[index])
+			if !childOK {
+				addIssue(fmt.Errorf("%w: fk_field2 %s.%s", ErrFieldNotFound, refType, fk.fkField2[index]))
+			}
+			if !parentOK || !childOK {
+				continue
+			}
+			parentRegistry, parentErr := getRegistryOfType(parentField, options)
+			childRegistry, childErr := getRegistryOfType(childField, options)
+			if parentErr != nil || childErr != nil {
+				/* reported by validateModelType of each side */
+				continue
+			}
+			if !isCompatibleRegistry(parentRegistry, childRegistry) {
+				addIssue(fmt.Errorf("%w: %s (%s) and %s.%s (%s)", ErrIncompatibleFkType,
+					parentField.Name, parentRegistry.TypeName(), refType, childField.Name, childRegistry.TypeName()))
+			}
+		}
+	}
+
+	return append(issues, validateModelType(refType, visited, options)...)
+}
+
+/*
+getRegistryOfType same rule as fieldMeta.getRegistry
+but not found or not inferable field is error
+*/
+func getRegistryOfType(field reflect.StructField, options MapperOption) (Registry, error) {
+	tag := strings.TrimSpace(field.Tag.Get(TAG_TYPE))
+	switch tag {
+	case "-":
+		return nil, nil
+	case "":
+		if registry, ok := inferRegistry(field.Type); ok {
+			return registry, nil
+		}
+		return nil, fmt.Errorf("%w: can not infer from %s, add type tag", ErrRegistryNotFound, field.Type)
+	}
+	registry, ok := options.getRegistry(tag)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrRegistryNotFound, tag)
+	}
+	return registry, nil
+}
+
+// isCompatibleRegistry same registry or accept the same column group (uuid and zerouuid, int32 and int64)
+func isCompatibleRegistry(x Registry, y Registry) bool {
+	if x == nil || y == nil {
+		return x == nil && y == nil
+	}
+	if x.TypeName() == y.TypeName() {
+		return true
+	}
+	xGroups, xOK := registryColumnGroups[x.TypeName()]
+	yGroups, yOK := registryColumnGroups[y.TypeName()]
+	return xOK && yOK && reflect.DeepEqual(xGroups, yGroups)
+}
@@ -0,0 +1,75 @@
+package orm_test
+
+import (
+	"errors"
+	"testing"
+
+	helperModel "github.com/Pheethy/psql/helper"
+	"github.com/Pheethy/psql/orm"
+	"github.com/stretchr/testify/assert"
+)
+
+type BadParent struct {
+	TableName struct{}    `json:"-" db:"bad_parents"`
+	ID        int         `json:"id" db:"id"`
+	Code      string      `json:"code" db:"code" type:"not_registered"`
+	Alias     string      `json:"alias" db:"code"`
+	Meta      interface{} `json:"meta" db:"meta"`
+	Children  []*BadChild `json:"children" db:"-" fk:"fk_field1:ID,fk_field2:ParentCode"`
+	Broken    *BadChild   `json:"broken" db:"-" fk:"fk_field1ID,fk_field2:ID"`
+	Missing   *BadChild   `json:"missing" db:"-" fk:"fk_field1:ID,fk_field2:Unknown"`
+}
+
+type BadChild struct {
+	TableName  struct{} `json:"-" db:"bad_children" pk:"ID"`
+	ID         int      `json:"id" db:"id"`
+	ParentCode string   `json:"parent_code" db:"parent_code"`
+}
+
+type UnmappedKey struct {
+	TableName struct{} `json:"-" db:"unmapped_keys" pk:"ID,Code"`
+	ID        int      `json:"id" db:"-"`
+	Code      string   `json:"code"`
+}
+
+func TestValidateModels(t *testing.T) {
+	t.Run("success_valid_models", func(t *testing.T) {
+		assert.NoError(t, orm.ValidateModels(new(Order), new(Menu), new(SortedMenu), MenuItem{}))
+	})
+
+	t.Run("error_collect_every_problem", func(t *testing.T) {
+		err := orm.ValidateModels(new(BadParent))
+		assert.Error(t, err)
+		assert.ErrorIs(t, err, orm.ErrPrimaryKeyNotFound)
+		assert.ErrorIs(t, err, orm.ErrRegistryNotFound)
+		assert.ErrorIs(t, err, orm.ErrDuplicateColumn)
+		assert.ErrorIs(t, err, orm.ErrIncompatibleFkType)
+		assert.ErrorIs(t, err, orm.ErrNotIdentifyFkField)
+		assert.ErrorIs(t, err, orm.ErrFieldNotFound)
+
+		var problems = err.(interface{ Unwrap() []error }).Unwrap()
+		assert.Len(t, problems, 7)
+		var mappingErr *orm.MappingError
+		assert.True(t, errors.As(problems[0], &mappingErr))
+		assert.Equal(t, "orm_test.BadParent", mappingErr.Model)
+		assert.Contains(t, err.Error(), "field orm_test.BadParent.Children")
+		assert.Contains(t, err.Error(), "field orm_test.BadParent.Meta")
+	})
+
+	t.Run("success_with_mapper_registry", func(t *testing.T) {
+		assert.ErrorIs(t, orm.ValidateModels(new(Invoice)), orm.ErrRegistryNotFound)
+		option := orm.NewMapperOption().SetRegistry("nulluuid", orm.NewScannerRegistry("nulluuid", helperModel.NullUUID{}))
+		assert.NoError(t, orm.ValidateModelsWithOption(option, new(Invoice)))
+	})
+
+	t.Run("error_primary_key_not_mapped", func(t *testing.T) {
+		err := orm.ValidateModels(new(UnmappedKey))
+		assert.ErrorIs(t, err, orm.ErrColumnNotFound)
+		assert.Len(t, err.(interface{ Unwrap() []error }).Unwrap(), 2)
+	})
+
+	t.Run("error_nil_model", func(t *testing.T) {
+		var menu *Menu
+		assert.ErrorIs(t, orm.ValidateModels(menu), orm.ErrMustNotNil)
+	})
+}
@@ -0,0 +1,380 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+
+	"github.com/Pheethy/sqlx"
+	"github.com/spf13/cast"
+)
+
+type mapperOptionKey struct{}
+
+/*
+WithMapperOption write helpers (Insert, Update, Delete, Upsert) use registry of options
+(SetRegistry) to encode value and bind RETURNING, same as Orm with options
+*/
+func WithMapperOption(ctx context.Context, options MapperOption) context.Context {
+	return context.WithValue(ctx, mapperOptionKey{}, options)
+}
+
+func mapperOptionFrom(ctx context.Context) MapperOption {
+	if options, ok := ctx.Value(mapperOptionKey{}).(MapperOption); ok {
+		return options
+	}
+	return NewMapperOption()
+}
+
+/*
+Insert model into table from TableName tag, columns from db tag.
+nil value and zero primary key write as DEFAULT and
+all columns RETURNING back into model (generated id, default timestamp).
+zero autoCreateTime, autoUpdateTime is set to now and zero version to 1
+*/
+func Insert(ctx context.Context, exec sqlx.ExtContext, model interface{}) error {
+	return InsertBatch(ctx, exec, []interface{}{model})
+}
+
+/*
+InsertBatch insert slice of model pointer with multi rows VALUES
+example []*Order{...}
+*/
+func InsertBatch(ctx context.Context, exec sqlx.ExtContext, models interface{}) error {
+	elems, err := getModelValues(models)
+	if err != nil || len(elems) == 0 {
+		return err
+	}
+	wm, err := newWriteModel(elems[0].Interface(), mapperOptionFrom(ctx))
+	if err != nil {
+		return err
+	}
+	if err := callHooks(ctx, hookBeforeInsert, elems); err != nil {
+		return err
+	}
+	if err := wm.stampInsert(elems); err != nil {
+		return err
+	}
+
+	err = wm.auditTx(ctx, exec, func(exec sqlx.ExtContext) error {
+		chunkSize := maxBindParameters / len(wm.columns)
+		for start := 0; start < len(elems); start += chunkSize {
+			end := start + chunkSize
+			if end > len(elems) {
+				end = len(elems)
+			}
+			stmt, err := wm.insertStatement(elems[start:end])
+			if err != nil {
+				return err
+			}
+			if _, err := queryReturning(ctx, exec, stmt, elems[start:end], wm.options); err != nil {
+				return err
+			}
+		}
+		return wm.auditInsert(ctx, exec, elems)
+	})
+	if err != nil {
+		return err
+	}
+	return callHooks(ctx, hookAfterInsert, elems)
+}
+
+/*
+Update model by primary key, columns is optional db column name to update
+if empty update every column except primary key.
+autoUpdateTime column is always set to now, version column is incremented and checked in WHERE.
+return sql.ErrNoRows when no row match primary key, ErrStaleObject when model has version column
+*/
+func Update(ctx context.Context, exec sqlx.ExtContext, model interface{}, columns ...string) error {
+	return UpdateBatch(ctx, exec, []interface{}{model}, columns...)
+}
+
+// UpdateBatch execute one UPDATE statement per model
+func UpdateBatch(ctx context.Context, exec sqlx.ExtContext, models interface{}, columns ...string) error {
+	return updateBatch(ctx, exec, models, func(wm writeModel, elem reflect.Value) (bool, error) {
+		return true, nil
+	}, func(wm writeModel, elem reflect.Value) ([]string, bool, error) {
+		return columns, true, nil
+	})
+}
+
+/*
+updateBatch changed select model to update before BeforeUpdate, hooks are called only on selected model.
+columnsOf return update columns of model after BeforeUpdate so column changed by hook is updated too,
+model is skipped when columnsOf return false (hook revert the change).
+autoUpdateTime and version of model which is not written is restored when update is failed
+*/
+func updateBatch(ctx context.Context, exec sqlx.ExtContext, models interface{}, changed func(wm writeModel, elem reflect.Value) (bool, error), columnsOf func(wm writeModel, elem reflect.Value) ([]string, bool, error)) error {
+	elems, err := getModelValues(models)
+	if err != nil || len(elems) == 0 {
+		return err
+	}
+	wm, err := newWriteModel(elems[0].Interface(), mapperOptionFrom(ctx))
+	if err != nil {
+		return err
+	}
+
+	var selected = make([]reflect.Value, 0, len(elems))
+	for _, elem := range elems {
+		ok, err := changed(wm, elem)
+		if err != nil {
+			return err
+		}
+		if ok {
+			selected = append(selected, elem)
+		}
+	}
+	if len(selected) == 0 {
+		return nil
+	}
+	if err := callHooks(ctx, hookBeforeUpdate, selected); err != nil {
+		return err
+	}
+	var updates = make([]reflect.Value, 0, len(selected))
+	var updateColumns = make([][]string, 0, len(selected))
+	for _, elem := range selected {
+		columns, ok, err := columnsOf(wm, elem)
+		if err != nil {
+			return err
+		}
+		if ok {
+			updates = append(updates, elem)
+			updateColumns = append(updateColumns, columns)
+		}
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	/* backup of each model, stamp of written model is kept without transaction */
+	var backups = make([][]fieldBackup, 0, len(updates))
+	for _, elem := range updates {
+		backups = append(backups, wm.backupStamp([]reflect.Value{elem}))
+	}
+	var restore = func(from int) {
+		for _, backup := range backups[from:] {
+			restoreFields(backup)
+		}
+	}
+	if err := wm.stampUpdate(updates); err != nil {
+		restore(0)
+		return err
+	}
+
+	var written int
+	err = wm.auditTx(ctx, exec, func(exec sqlx.ExtContext) error {
+		olds, err := wm.auditSelect(ctx, exec, updates, wm.pkColumns(), "")
+		if err != nil {
+			return err
+		}
+		for index, elem := range updates {
+			if err := wm.update(ctx, exec, elem, updateColumns[index]); err != nil {
+				return err
+			}
+			written = index + 1
+		}
+		return wm.auditUpdate(ctx, exec, updates, olds)
+	})
+	if err != nil {
+		/* written model is kept only without transaction */
+		if wm.inTransaction(exec) {
+			written = 0
+		}
+		restore(written)
+		return err
+	}
+	return callHooks(ctx, hookAfterUpdate, updates)
+}
+
+func (w writeModel) update(ctx context.Context, exec sqlx.ExtContext, elem reflect.Value, columns []string) error {
+	stmt, err := w.updateStatement(elem, columns)
+	if err != nil {
+		return err
+	}
+	count, err := queryReturning(ctx, exec, stmt, []reflect.Value{elem}, w.options)
+	if err != nil {
+		return err
+	}
+	if count == 0 && w.version != nil {
+		return ErrStaleObject
+	}
+	if count == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+/*
+Delete model by primary key,
+model with `softdelete:"true"` field is UPDATE soft delete column (now or true) instead
+*/
+func Delete(ctx context.Context, exec sqlx.ExtContext, model interface{}) error {
+	return DeleteBatch(ctx, exec, []interface{}{model})
+}
+
+// DeleteBatch delete slice of model pointer with WHERE (pk) IN (...), soft delete same as Delete
+func DeleteBatch(ctx context.Context, exec sqlx.ExtContext, models interface{}) error {
+	return deleteBatch(ctx, exec, models, false)
+}
+
+// ForceDelete DELETE model by primary key even model is soft delete
+func ForceDelete(ctx context.Context, exec sqlx.ExtContext, model interface{}) error {
+	return ForceDeleteBatch(ctx, exec, []interface{}{model})
+}
+
+// ForceDeleteBatch DELETE slice of model pointer even model is soft delete
+func ForceDeleteBatch(ctx context.Context, exec sqlx.ExtContext, models interface{}) error {
+	return deleteBatch(ctx, exec, models, true)
+}
+
+func deleteBatch(ctx context.Context, exec sqlx.ExtContext, models interface{}, force bool) error {
+	elems, err := getModelValues(models)
+	if err != nil || len(elems) == 0 {
+		return err
+	}
+	wm, err := newWriteModel(elems[0].Interface(), mapperOptionFrom(ctx))
+	if err != nil {
+		return err
+	}
+	if err := callHooks(ctx, hookBeforeDelete, elems); err != nil {
+		return err
+	}
+	var softDelete = wm.softDelete != nil && !force
+	var deletedAt = now()
+	var deletedValue interface{}
+	var condition string
+	if softDelete {
+		deletedValue = wm.softDelete.deletedValue(deletedAt)
+		condition = wm.notDeletedCondition()
+	}
+	var backups = backupFields(elems, wm.softDelete, wm.updateTime, wm.version)
+	err = wm.auditTx(ctx, exec, func(exec sqlx.ExtContext) error {
+		/* soft deleted row is not deleted again */
+		olds, err := wm.auditSelect(ctx, exec, elems, wm.pkColumns(), condition)
+		if err != nil {
+			return err
+		}
+
+		chunkSize := (maxBindParameters - 2) / (len(wm.pkFields) + 1)
+		for start := 0; start < len(elems); start += chunkSize {
+			end := start + chunkSize
+			if end > len(elems) {
+				end = len(elems)
+			}
+			var stmt statement
+			if softDelete {
+				stmt, err = wm.softDeleteStatement(elems[start:end], deletedValue, deletedAt)
+			} else {
+				stmt, err = wm.deleteStatement(elems[start:end])
+			}
+			if err != nil {
+				return err
+			}
+			result, err := exec.ExecContext(ctx, stmt.query, stmt.args...)
+			if err != nil {
+				return err
+			}
+			/* row of other version is not deleted, same as Update */
+			if softDelete && wm.version != nil {
+				affected, err := result.RowsAffected()
+				if err != nil {
+					return err
+				}
+				if affected < int64(end-start) {
+					return ErrStaleObject
+				}
+			}
+		}
+
+		if softDelete {
+			/* soft delete column, autoUpdateTime and version of model are set like RETURNING */
+			for row, elem := range elems {
+				if err := wm.setField(elem, row, wm.softDelete, deletedValue); err != nil {
+					return err
+				}
+				if wm.updateTime != nil {
+					if err := wm.setField(elem, row, wm.updateTime, deletedAt); err != nil {
+						return err
+					}
+				}
+				if wm.version != nil {
+					if err := wm.setField(elem, row, wm.version, cast.ToInt64(wm.version.value(elem))+1); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return wm.auditDelete(ctx, exec, elems, olds)
+	})
+	if err != nil {
+		/* audit is failed after model is set, write is rolled back */
+		restoreFields(backups)
+		return err
+	}
+	return callHooks(ctx, hookAfterDelete, elems)
+}
+
+/*
+queryReturning scan RETURNING rows back into models by row order
+return count of returning rows
+*/
+func queryReturning(ctx context.Context, exec sqlx.ExtContext, stmt statement, models []reflect.Value, options MapperOption) (int, error) {
+	rows, err := exec.QueryxContext(ctx, stmt.query, stmt.args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	for rows.Next() {
+		values, err := rows.SliceScan()
+		if err != nil {
+			return count, err
+		}
+		if count < len(models) {
+			if err := fillValue(models[count].Interface(), columns, values, count, options); err != nil {
+				return count, err
+			}
+		}
+		count++
+	}
+	return count, rows.Err()
+}
+
+/*
+getModelValues accept []*Model or []interface{} of model pointer
+every model must be the same type
+*/
+func getModelValues(models interface{}) ([]reflect.Value, error) {
+	slice := reflect.ValueOf(models)
+	if slice.Kind() != reflect.Slice {
+		return nil, ErrMustBeSlice
+	}
+
+	var elems = make([]reflect.Value, 0, slice.Len())
+	var modelType reflect.Type
+	for index := 0; index < slice.Len(); index++ {
+		elem := slice.Index(index)
+		if elem.Kind() == reflect.Interface {
+			elem = elem.Elem()
+		}
+		if elem.Kind() != reflect.Ptr {
+			return nil, ErrMustBeSlice
+		}
+		if err := validateModel(elem.Interface()); err != nil {
+			return nil, err
+		}
+		if modelType == nil {
+			modelType = elem.Type()
+		}
+		if elem.Type() != modelType {
+			return nil, ErrMustBeSlice
+		}
+		elems = append(elems, elem)
+	}
+	return elems, nil
+}
@@ -0,0 +1,252 @@
+package orm_test
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+
+	helperModel "github.com/Pheethy/psql/helper"
+	"github.com/Pheethy/psql/orm"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v2"
+)
+
+func TestWriter(t *testing.T) {
+	var returningColumns = []string{"id", "type", "name", "ppu", "status", "enable", "order_date", "created_at", "chef_id"}
+
+	t.Run("success_insert_returning_generated_id", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		defer db.Close()
+
+		orderDate := helperModel.NewDateFromString("2023-06-01")
+		order := &Order{Type: "donut", Name: "Cake", Ppu: 0.55, Status: 1, Enable: true, OrderDate: &orderDate}
+		id, _ := uuid.NewV4()
+
+		sql := `INSERT INTO "orders" ("id","type","name","ppu","status","enable","order_date","created_at","chef_id") ` +
+			`VALUES (DEFAULT,$1,$2,$3,$4,$5,$6,DEFAULT,$7) ` +
+			`RETURNING "id","type","name","ppu","status","enable","order_date","created_at","chef_id"`
+		dbmock.ExpectQuery(regexp.QuoteMeta(sql)).
+			WithArgs("donut", "Cake", 0.55, 1, true, "2023-06-01", nil).
+			WillReturnRows(sqlmock.NewRows(returningColumns).AddRow(
+				id.String(), "donut", "Cake", 0.55, 1, true, "2023-06-01", "2023-06-01 10:00:00", nil,
+			))
+
+		err := orm.Insert(context.Background(), db, order)
+		assert.NoError(t, err)
+		assert.Equal(t, id.String(), order.ID.String())
+		assert.Equal(t, "2023-06-01 10:00:00", order.CreatedAt.String())
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("success_insert_batch", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		defer db.Close()
+
+		toppings := []*Topping{{Type: "None"}, {Type: "Glazed"}}
+		sql := `INSERT INTO "toppings" ("id","type","order_id") VALUES (DEFAULT,$1,$2),(DEFAULT,$3,$4) RETURNING "id","type","order_id"`
+		dbmock.ExpectQuery(regexp.QuoteMeta(sql)).
+			WithArgs("None", nil, "Glazed", nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "type", "order_id"}).
+				AddRow(5001, "None", nil).
+				AddRow(5002, "Glazed", nil),
+			)
+
+		err := orm.InsertBatch(context.Background(), db, toppings)
+		assert.NoError(t, err)
+		assert.Equal(t, 5001, toppings[0].ID)
+		assert.Equal(t, 5002, toppings[1].ID)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("success_insert_composite_key_with_zero_component", func(t *testing.T) {
+		db, dbmock := newDB(t)
+
+		line := &SalesOrderLine{OrderNo: 1, Branch: 0, LineNo: 0, IsGift: false, Qty: 5}
+		sql := `INSERT INTO "sales_order_lines" ("order_no","branch","line_no","is_gift","qty") VALUES ($1,$2,$3,$4,$5) ` +
+			`RETURNING "order_no","branch","line_no","is_gift","qty"`
+		dbmock.ExpectQuery(regexp.QuoteMeta(sql)).
+			WithArgs(1, 0, 0, false, 5).
+			WillReturnRows(sqlmock.NewRows([]string{"order_no", "branch", "line_no", "is_gift", "qty"}).AddRow(1, 0, 0, false, 5))
+
+		err := orm.Insert(context.Background(), db, line)
+		assert.NoError(t, err)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("success_update_with_columns", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		defer db.Close()
+
+		id, _ := uuid.NewV4()
+		order := &Order{ID: &id, Name: "Raised", Status: 2}
+		sql := `UPDATE "orders" SET "name" = $1,"status" = $2 WHERE "id" = $3 ` +
+			`RETURNING "id","type","name","ppu","status","enable","order_date","created_at","chef_id"`
+		dbmock.ExpectQuery(regexp.QuoteMeta(sql)).
+			WithArgs("Raised", 2, id.String()).
+			WillReturnRows(sqlmock.NewRows(returningColumns).AddRow(
+				id.String(), "donut", "Raised", 0.55, 2, true, "2023-06-01", "2023-06-01 10:00:00", nil,
+			))
+
+		err := orm.Update(context.Background(), db, order, "name", "status")
+		assert.NoError(t, err)
+		assert.Equal(t, "donut", order.Type)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("error_update_no_rows", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		defer db.Close()
+
+		order := &Topping{ID: 5001, Type: "None"}
+		dbmock.ExpectQuery(regexp.QuoteMeta(`UPDATE "toppings"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "type", "order_id"}))
+
+		err := orm.Update(context.Background(), db, order)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("error_update_unknown_column", func(t *testing.T) {
+		db, _ := newDB(t)
+		defer db.Close()
+
+		err := orm.Update(context.Background(), db, &Topping{ID: 5001}, "unknown")
+		assert.ErrorIs(t, err, orm.ErrColumnNotFound)
+	})
+
+	t.Run("success_delete_batch", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		defer db.Close()
+
+		sql := `DELETE FROM "toppings" WHERE ("id") IN (($1),($2))`
+		dbmock.ExpectExec(regexp.QuoteMeta(sql)).
+			WithArgs(5001, 5002).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		err := orm.DeleteBatch(context.Background(), db, []*Topping{{ID: 5001}, {ID: 5002}})
+		assert.NoError(t, err)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("error_delete_empty_pk", func(t *testing.T) {
+		db, _ := newDB(t)
+		defer db.Close()
+
+		err := orm.Delete(context.Background(), db, &Order{})
+		assert.ErrorIs(t, err, orm.ErrPrimaryKeyIsEmpty)
+	})
+
+	t.Run("success_upsert_default_conflict_pk", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		defer db.Close()
+
+		batter := &Batter{ID: "1001", Type: "Regular"}
+		sql := `INSERT INTO "batters" ("id","type","order_id") VALUES ($1,$2,$3) ` +
+			`ON CONFLICT ("id") DO UPDATE SET "type" = EXCLUDED."type","order_id" = EXCLUDED."order_id" ` +
+			`RETURNING "id","type","order_id"`
+		dbmock.ExpectQuery(regexp.QuoteMeta(sql)).
+			WithArgs("1001", "Regular", nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "type", "order_id"}).AddRow("1001", "Regular", nil))
+
+		err := orm.Upsert(context.Background(), db, batter, orm.NewUpsertOption())
+		assert.NoError(t, err)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("success_upsert_batch_with_where_map_returning_by_conflict_key", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		defer db.Close()
+
+		orderID, _ := uuid.NewV4()
+		batters := []*Batter{{ID: "1001", Type: "Regular"}, {ID: "1002", Type: "Chocolate"}}
+		sql := `INSERT INTO "batters" ("id","type","order_id") VALUES ($1,$2,$3),($4,$5,$6) ` +
+			`ON CONFLICT ("id") DO UPDATE SET "type" = EXCLUDED."type" WHERE "batters"."order_id" = $7 ` +
+			`RETURNING "id","type","order_id"`
+		dbmock.ExpectQuery(regexp.QuoteMeta(sql)).
+			WithArgs("1001", "Regular", nil, "1002", "Chocolate", nil, orderID.String()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "type", "order_id"}).AddRow("1002", "Chocolate", orderID.String()))
+
+		option := orm.NewUpsertOption().
+			SetUpdateColumns("type").
+			SetWhere(`"batters"."order_id" = $1`, orderID.String())
+		err := orm.UpsertBatch(context.Background(), db, batters, option)
+		assert.NoError(t, err)
+		assert.Nil(t, batters[0].OrderId)
+		assert.Equal(t, orderID.String(), batters[1].OrderId.String())
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("success_upsert_do_nothing_with_conflict_columns", func(t *testing.T) {
+		db, dbmock := newDB(t)
+		defer db.Close()
+
+		sql := `INSERT INTO "toppings" ("id","type","order_id") VALUES (DEFAULT,$1,$2) ` +
+			`ON CONFLICT ("type") DO NOTHING RETURNING "id","type","order_id"`
+		dbmock.ExpectQuery(regexp.QuoteMeta(sql)).
+			WithArgs("Glazed", nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "type", "order_id"}))
+
+		topping := &Topping{Type: "Glazed"}
+		err := orm.Upsert(context.Background(), db, topping, orm.NewUpsertOption().SetConflictColumns("type").SetDoNothing())
+		assert.NoError(t, err)
+		assert.Zero(t, topping.ID)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("success_upsert_where_keep_literal_placeholder", func(t *testing.T) {
+		db, dbmock := newDB(t)
+
+		sql := `INSERT INTO "toppings" ("id","type","order_id") VALUES ($1,$2,$3) ` +
+			`ON CONFLICT ("id") DO UPDATE SET "type" = EXCLUDED."type" ` +
+			`WHERE "toppings"."type" <> '$1' AND "toppings"."type" <> $$ $2 $$ AND "toppings"."type" <> $4 ` +
+			`RETURNING "id","type","order_id"`
+		dbmock.ExpectQuery(regexp.QuoteMeta(sql)).
+			WithArgs(5001, "Glazed", nil, "None").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "type", "order_id"}).AddRow(5001, "Glazed", nil))
+
+		option := orm.NewUpsertOption().
+			SetUpdateColumns("type").
+			SetWhere(`"toppings"."type" <> '$1' AND "toppings"."type" <> $$ $2 $$ AND "toppings"."type" <> $1`, "None")
+		err := orm.Upsert(context.Background(), db, &Topping{ID: 5001, Type: "Glazed"}, option)
+		assert.NoError(t, err)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("success_upsert_batch_new_rows_with_default_pk", func(t *testing.T) {
+		db, dbmock := newDB(t)
+
+		toppings := []*Topping{{Type: "None"}, {ID: 5001, Type: "Glazed"}, {Type: "Sugar"}}
+		sql := `INSERT INTO "toppings" ("id","type","order_id") VALUES (DEFAULT,$1,$2),($3,$4,$5),(DEFAULT,$6,$7) ` +
+			`ON CONFLICT ("id") DO UPDATE SET "type" = EXCLUDED."type","order_id" = EXCLUDED."order_id" ` +
+			`RETURNING "id","type","order_id"`
+		/* existing row is matched by key even when it is not in VALUES order */
+		dbmock.ExpectQuery(regexp.QuoteMeta(sql)).
+			WithArgs("None", nil, 5001, "Glazed", nil, "Sugar", nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "type", "order_id"}).
+				AddRow(5001, "Glazed", nil).
+				AddRow(6001, "None", nil).
+				AddRow(6002, "Sugar", nil))
+
+		err := orm.UpsertBatch(context.Background(), db, toppings, orm.NewUpsertOption())
+		assert.NoError(t, err)
+		assert.Equal(t, 6001, toppings[0].ID)
+		assert.Equal(t, 5001, toppings[1].ID)
+		assert.Equal(t, 6002, toppings[2].ID)
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+
+	t.Run("error_upsert_duplicate_conflict_key", func(t *testing.T) {
+		db, dbmock := newDB(t)
+
+		toppings := []*Topping{{ID: 5001, Type: "None"}, {Type: "Glazed"}, {ID: 5001, Type: "Sugar"}}
+		err := orm.UpsertBatch(context.Background(), db, toppings, orm.NewUpsertOption())
+		assert.ErrorIs(t, err, orm.ErrDuplicateConflict)
+		var mappingErr *orm.MappingError
+		if assert.ErrorAs(t, err, &mappingErr) {
+			assert.Equal(t, 2, mappingErr.Row)
+			assert.Equal(t, "id", mappingErr.Column)
+		}
+		assert.NoError(t, dbmock.ExpectationsWereMet())
+	})
+}